@@ -147,6 +147,7 @@ func evaluator_fn(
 	inputSizes := make([][]int, 2)
 	myInputSizes, err := circuit.InputSizes(args)
 	if err != nil {
+		conn.Abort(ot.AbortInput, err)
 		return nil, errors.Wrap(err, "in evaluator_fn()")
 	}
 	inputSizes[1] = myInputSizes
@@ -168,6 +169,7 @@ func evaluator_fn(
 	if slices.Compare(peerInputSizes, oPeerInputSizes) != 0 {
 		circ, err = loadCircuit(circ_file, params, inputSizes)
 		if err != nil {
+			conn.Abort(ot.AbortProtocol, err)
 			conn.Close()
 			return nil, errors.Wrap(err, "in evaluator_fn()")
 		}
@@ -181,15 +183,20 @@ func evaluator_fn(
 
 	input, err := circ.Inputs[1].Parse(args)
 	if err != nil {
+		conn.Abort(ot.AbortInput, err)
 		conn.Close()
 		return nil, errors.Wrapf(err, "in evaluator_fn(), filepath=%s", circ)
 	}
-	result, err := circuit.Evaluator(conn, oti, circ, input, false)
+	result, err := circuit.Evaluator(params.Config, conn, oti, circ, input,
+		false)
 	conn.Close()
 	if err != nil && err != io.EOF {
 		return nil, errors.Wrapf(err, "in evaluator_fn(), filepath=%s", circ)
 	}
-	val := getResult(result, circ.Outputs)
+	val, err := getResult(result, circ.Outputs)
+	if err != nil {
+		return nil, errors.Wrap(err, "in evaluator_fn()")
+	}
 	if val[0] != 0 {
 		return val[1:], nil
 	} else {
@@ -223,6 +230,7 @@ func garbler_fn(
 	inputSizes := make([][]int, 2)
 	myInputSizes, err := circuit.InputSizes(args)
 	if err != nil {
+		conn.Abort(ot.AbortInput, err)
 		return nil, errors.Wrap(err, "in garbler_fn()")
 	}
 	inputSizes[0] = myInputSizes
@@ -241,6 +249,7 @@ func garbler_fn(
 
 	circ, err := loadCircuit(circ_file, params, inputSizes)
 	if err != nil {
+		conn.Abort(ot.AbortProtocol, err)
 		return nil, errors.Wrap(err, "in garbler_fn()")
 	}
 	if len(circ.Inputs) != 2 {
@@ -251,13 +260,17 @@ func garbler_fn(
 
 	input, err := circ.Inputs[0].Parse(args)
 	if err != nil {
+		conn.Abort(ot.AbortInput, err)
 		return nil, errors.Wrapf(err, "in garbler_fn(), filepath=%s", circ)
 	}
 	result, err := circuit.Garbler(params.Config, conn, oti, circ, input, false)
 	if err != nil {
 		return nil, errors.Wrap(err, "in garbler_fn()")
 	}
-	val := getResult(result, circ.Outputs)
+	val, err := getResult(result, circ.Outputs)
+	if err != nil {
+		return nil, errors.Wrap(err, "in garbler_fn()")
+	}
 	if val[0] != 0 {
 		return val[1:], nil
 	} else {
@@ -297,9 +310,26 @@ func loadCircuit(
 	return circ, err
 }
 
-func getResult(results []*big.Int, outputs circuit.IO) []byte {
-	val := Results(results, outputs)[0].([]byte)
-	return val
+// getResult returns the first output of the results. The function
+// returns an error if the output is not revealed to this party or if
+// it is not a byte array of the status byte and the value.
+func getResult(results []*big.Int, outputs circuit.IO) ([]byte, error) {
+	if len(results) == 0 || len(outputs) == 0 {
+		return nil, errors.New("circuit has no outputs")
+	}
+	if results[0] == nil {
+		return nil, errors.Newf("output %v not revealed to this party",
+			outputs[0])
+	}
+	val, ok := Results(results, outputs)[0].([]byte)
+	if !ok {
+		return nil, errors.Newf("output %v is not a byte array", outputs[0])
+	}
+	if len(val) < 2 {
+		return nil, errors.Newf("output %v too short: %d bytes",
+			outputs[0], len(val))
+	}
+	return val, nil
 }
 
 type InputArguments []string
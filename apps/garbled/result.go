@@ -90,7 +90,9 @@ func Results(results []*big.Int, outputs circuit.IO) []interface{} {
 
 	for idx, result := range results {
 		var r interface{}
-		if outputs == nil {
+		if result == nil {
+			// Output not revealed to this party.
+		} else if outputs == nil {
 			r = Result(result, circuit.IOArg{
 				Type: types.Info{
 					Type:       types.TUint,
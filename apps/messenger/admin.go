@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const adminUsage = `usage: messenger admin [flags] <command> [args]
+
+Commands:
+  sessions        list live sessions
+  messages <sid>  list pending messages of the session
+  expire <sid>    force-expire the session and its messages
+
+Flags:
+`
+
+func adminMain(args []string) error {
+	fs := flag.NewFlagSet("admin", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:65534", "messenger address")
+	token := fs.String("token", os.Getenv("MESSENGER_ADMIN_TOKEN"),
+		"admin API token (default $MESSENGER_ADMIN_TOKEN)")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), adminUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := grpc.NewClient(*addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx = ot.AdminContext(ctx, *token)
+
+	stub := pb.NewMpcSessionAdminClient(conn)
+
+	cmd := fs.Arg(0)
+	switch cmd {
+	case "sessions":
+		resp, err := stub.ListSessions(ctx, &pb.Void{})
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SESSION\tMESSAGES\tBYTES\tEXPIRES\tCONFIG")
+		for _, s := range resp.Sessions {
+			expires := "-"
+			if s.ExpireAtUnix > 0 {
+				expires = time.Unix(s.ExpireAtUnix, 0).Format(time.RFC3339)
+			}
+			config := "-"
+			if s.Config != nil {
+				config = protojson.Format(s.Config)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", s.SessionId,
+				s.PendingMessages, s.PendingBytes, expires, config)
+		}
+		return w.Flush()
+
+	case "messages":
+		if fs.NArg() != 2 {
+			return errors.Newf("usage: messenger admin messages <sid>")
+		}
+		resp, err := stub.ListMessages(ctx, &pb.SessionId{Value: fs.Arg(1)})
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "TOPIC\tSRC\tDST\tSEQ\tSIZE\tAGE")
+		for _, m := range resp.Messages {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", m.Topic, m.Src, m.Dst,
+				m.Seq, m.Size, time.Duration(m.AgeMs)*time.Millisecond)
+		}
+		return w.Flush()
+
+	case "expire":
+		if fs.NArg() != 2 {
+			return errors.Newf("usage: messenger admin expire <sid>")
+		}
+		resp, err := stub.ExpireSession(ctx, &pb.SessionId{Value: fs.Arg(1)})
+		if err != nil {
+			return err
+		}
+		if !resp.SessionFound {
+			return errors.Newf("session %s not found", fs.Arg(1))
+		}
+		fmt.Printf("expired session %s: %d messages\n",
+			fs.Arg(1), resp.ExpiredMessages)
+		return nil
+
+	default:
+		fs.Usage()
+		return errors.Newf("unknown admin command: %s", cmd)
+	}
+}
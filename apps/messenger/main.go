@@ -1,12 +1,64 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/markkurossi/mpc/ot"
 )
 
 func main() {
-	fmt.Println("Messenger server will listen at 127.0.0.1:65534 ...")
-	ot.SpawnServer("127.0.0.1", 65534)
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		if err := adminMain(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg := ot.DefaultServerConfig()
+	cfg.AdminToken = os.Getenv("MESSENGER_ADMIN_TOKEN")
+
+	flag.StringVar(&cfg.Addr, "listen", cfg.Addr, "listen address")
+	flag.StringVar(&cfg.HTTPAddr, "http", cfg.HTTPAddr,
+		"HTTP/JSON gateway listen address, gateway is disabled if empty")
+	flag.DurationVar(&cfg.SessionTTL, "session-ttl", cfg.SessionTTL,
+		"session and message time-to-live")
+	flag.DurationVar(&cfg.CleanupInterval, "cleanup-interval",
+		cfg.CleanupInterval, "interval for removing expired items")
+	flag.DurationVar(&cfg.DrainTimeout, "drain-timeout", cfg.DrainTimeout,
+		"maximum time to wait for in-flight sessions on shutdown")
+	flag.DurationVar(&cfg.DrainIdle, "drain-idle", cfg.DrainIdle,
+		"idle time after which in-flight sessions are considered finished")
+	flag.IntVar(&cfg.Limits.MaxMessageBytes, "max-message-bytes",
+		cfg.Limits.MaxMessageBytes, "maximum message size")
+	flag.Int64Var(&cfg.Limits.MaxSessionBytes, "max-session-bytes",
+		cfg.Limits.MaxSessionBytes, "maximum pending bytes per session")
+	flag.IntVar(&cfg.Limits.MaxSessionMessages, "max-session-messages",
+		cfg.Limits.MaxSessionMessages, "maximum pending messages per session")
+	flag.Int64Var(&cfg.Limits.MaxTotalBytes, "max-total-bytes",
+		cfg.Limits.MaxTotalBytes, "maximum pending bytes in total")
+	flag.IntVar(&cfg.Limits.MaxTotalMessages, "max-total-messages",
+		cfg.Limits.MaxTotalMessages, "maximum pending messages in total")
+	flag.Float64Var(&cfg.Limits.NewSessionRate, "new-session-rate",
+		cfg.Limits.NewSessionRate, "NewSession calls per second per caller")
+	flag.IntVar(&cfg.Limits.NewSessionBurst, "new-session-burst",
+		cfg.Limits.NewSessionBurst, "NewSession burst size per caller")
+	flag.StringVar(&cfg.AdminToken, "admin-token", cfg.AdminToken,
+		"admin API token, admin API is disabled if empty "+
+			"(default $MESSENGER_ADMIN_TOKEN)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Messenger server will listen at %s ...", cfg.Addr)
+	if err := ot.Serve(ctx, cfg); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Messenger server stopped")
 }
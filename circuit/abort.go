@@ -0,0 +1,55 @@
+//
+// abort.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// abortCode returns the abort reason code for the protocol error.
+func abortCode(err error) ot.AbortCode {
+	switch {
+	case errors.Is(err, ErrProtocolMismatch),
+		errors.Is(err, ErrCircuitMismatch),
+		errors.Is(err, ErrPublicInputMismatch),
+		errors.Is(err, ErrCheckpointMismatch),
+		errors.Is(err, ot.ErrResumeMismatch),
+		errors.Is(err, ot.ErrTranscriptMismatch),
+		errors.Is(err, ErrOutputMismatch):
+		return ot.AbortMismatch
+
+	case errors.Is(err, ErrInvalidOutputLabel),
+		errors.Is(err, ErrCheatingDetected),
+		errors.Is(err, ErrAuthenticationFailed),
+		errors.Is(err, ot.ErrCOTCheck):
+		return ot.AbortEvaluation
+
+	default:
+		return ot.AbortProtocol
+	}
+}
+
+// shouldAbort tests if the party should abort the protocol on error.
+// The messenger failures do not abort resumable sessions since the
+// session can be resumed after the messenger recovers.
+func shouldAbort(cfg *utils.Config, err error) bool {
+	if cfg.Checkpoints == nil {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return false
+	default:
+		return true
+	}
+}
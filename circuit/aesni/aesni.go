@@ -0,0 +1,156 @@
+//
+// aesni.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+// Package aesni implements AES block encryption of multiple blocks
+// with one call. The garbling schemes use AES as a fixed-key
+// permutation so the key is expanded once and the blocks of each gate
+// are encrypted together. On amd64 CPUs with the AES-NI instructions,
+// the blocks are encrypted in parallel in the AES pipeline. Other
+// CPUs encrypt the blocks one by one with crypto/aes.
+package aesni
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+)
+
+// BlockSize specifies the AES block size in bytes.
+const BlockSize = 16
+
+// Cipher implements AES encryption with a fixed key. The Cipher is
+// safe for concurrent use.
+type Cipher struct {
+	rounds int
+	// xk holds the expanded round keys in the AES byte order.
+	xk []byte
+	// block encrypts the blocks without AES-NI.
+	block cipher.Block
+}
+
+// New creates a new cipher with the key. The key must be 16, 24, or
+// 32 bytes long for AES-128, AES-192, or AES-256.
+func New(key []byte) (*Cipher, error) {
+	var rounds int
+	switch len(key) {
+	case 16:
+		rounds = 10
+	case 24:
+		rounds = 12
+	case 32:
+		rounds = 14
+	default:
+		return nil, fmt.Errorf("aesni: invalid key size %d", len(key))
+	}
+	c := &Cipher{
+		rounds: rounds,
+	}
+	if haveAESNI {
+		c.xk = make([]byte, BlockSize*(rounds+1))
+		expandKey(key, c.xk)
+	} else {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		c.block = block
+	}
+	return c, nil
+}
+
+// Accelerated tests if the cipher uses the AES-NI instructions.
+func (c *Cipher) Accelerated() bool {
+	return haveAESNI
+}
+
+// Encrypt encrypts the blocks of src into dst. The length of src must
+// be a multiple of BlockSize and dst must be at least as long as src.
+// The dst and src may overlap entirely but not partially.
+func (c *Cipher) Encrypt(dst, src []byte) {
+	if len(src)%BlockSize != 0 {
+		panic("aesni: input not full blocks")
+	}
+	if len(dst) < len(src) {
+		panic("aesni: output smaller than input")
+	}
+	if len(src) == 0 {
+		return
+	}
+	if haveAESNI {
+		encryptBlocks(c.rounds, &c.xk[0], &dst[0], &src[0],
+			len(src)/BlockSize)
+		return
+	}
+	for i := 0; i < len(src); i += BlockSize {
+		c.block.Encrypt(dst[i:i+BlockSize], src[i:i+BlockSize])
+	}
+}
+
+// expandKey expands the key into the round keys xk.
+func expandKey(key, xk []byte) {
+	nk := len(key) / 4
+	copy(xk, key)
+
+	rcon := byte(1)
+	for i := nk; i < len(xk)/4; i++ {
+		var t [4]byte
+		copy(t[:], xk[4*(i-1):4*i])
+		if i%nk == 0 {
+			t[0], t[1], t[2], t[3] =
+				sbox[t[1]]^rcon, sbox[t[2]], sbox[t[3]], sbox[t[0]]
+			rcon = xtime(rcon)
+		} else if nk > 6 && i%nk == 4 {
+			for j := range t {
+				t[j] = sbox[t[j]]
+			}
+		}
+		for j := range t {
+			xk[4*i+j] = xk[4*(i-nk)+j] ^ t[j]
+		}
+	}
+}
+
+// sbox holds the AES S-box for the key expansion. The key is the
+// public fixed key of the garbling schemes so the S-box lookups of the
+// key expansion need not be constant-time.
+var sbox [256]byte
+
+func init() {
+	// Iterate the multiplicative group of GF(2^8) with the generator
+	// 3 and its inverse. The S-box is the affine transformation of
+	// the multiplicative inverse.
+	p, q := byte(1), byte(1)
+	for {
+		p = p ^ xtime(p)
+
+		q ^= q << 1
+		q ^= q << 2
+		q ^= q << 4
+		if q&0x80 != 0 {
+			q ^= 0x09
+		}
+		sbox[p] = q ^ rotl8(q, 1) ^ rotl8(q, 2) ^ rotl8(q, 3) ^ rotl8(q, 4) ^
+			0x63
+		if p == 1 {
+			break
+		}
+	}
+	sbox[0] = 0x63
+}
+
+func rotl8(b byte, n int) byte {
+	return b<<n | b>>(8-n)
+}
+
+// xtime multiplies b by x in GF(2^8).
+func xtime(b byte) byte {
+	if b&0x80 != 0 {
+		return b<<1 ^ 0x1b
+	}
+	return b << 1
+}
@@ -0,0 +1,23 @@
+//
+// aesni_amd64.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+//go:build amd64 && !purego
+
+package aesni
+
+import (
+	"golang.org/x/sys/cpu"
+)
+
+var haveAESNI = cpu.X86.HasAES
+
+// encryptBlocks encrypts n blocks from src to dst with the expanded
+// round keys xk.
+//
+//go:noescape
+func encryptBlocks(rounds int, xk, dst, src *byte, n int)
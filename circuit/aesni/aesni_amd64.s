@@ -0,0 +1,97 @@
+//
+// aesni_amd64.s
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func encryptBlocks(rounds int, xk, dst, src *byte, n int)
+//
+// The blocks are encrypted four at a time so that the AESENC
+// instructions of the independent blocks fill the AES pipeline.
+TEXT ·encryptBlocks(SB), NOSPLIT, $0-40
+	MOVQ rounds+0(FP), CX
+	MOVQ xk+8(FP), AX
+	MOVQ dst+16(FP), DI
+	MOVQ src+24(FP), SI
+	MOVQ n+32(FP), DX
+
+loop4:
+	CMPQ DX, $4
+	JB   loop1
+
+	MOVOU 0(AX), X4
+	MOVOU 0(SI), X0
+	MOVOU 16(SI), X1
+	MOVOU 32(SI), X2
+	MOVOU 48(SI), X3
+	PXOR  X4, X0
+	PXOR  X4, X1
+	PXOR  X4, X2
+	PXOR  X4, X3
+
+	MOVQ AX, BX
+	MOVQ CX, R8
+	DECQ R8
+
+round4:
+	ADDQ   $16, BX
+	MOVOU  0(BX), X4
+	AESENC X4, X0
+	AESENC X4, X1
+	AESENC X4, X2
+	AESENC X4, X3
+	DECQ   R8
+	JNZ    round4
+
+	MOVOU      16(BX), X4
+	AESENCLAST X4, X0
+	AESENCLAST X4, X1
+	AESENCLAST X4, X2
+	AESENCLAST X4, X3
+
+	MOVOU X0, 0(DI)
+	MOVOU X1, 16(DI)
+	MOVOU X2, 32(DI)
+	MOVOU X3, 48(DI)
+
+	ADDQ $64, SI
+	ADDQ $64, DI
+	SUBQ $4, DX
+	JMP  loop4
+
+loop1:
+	TESTQ DX, DX
+	JZ    done
+
+	MOVOU 0(AX), X4
+	MOVOU 0(SI), X0
+	PXOR  X4, X0
+
+	MOVQ AX, BX
+	MOVQ CX, R8
+	DECQ R8
+
+round1:
+	ADDQ   $16, BX
+	MOVOU  0(BX), X4
+	AESENC X4, X0
+	DECQ   R8
+	JNZ    round1
+
+	MOVOU      16(BX), X4
+	AESENCLAST X4, X0
+	MOVOU      X0, 0(DI)
+
+	ADDQ $16, SI
+	ADDQ $16, DI
+	DECQ DX
+	JMP  loop1
+
+done:
+	RET
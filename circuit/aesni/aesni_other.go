@@ -0,0 +1,17 @@
+//
+// aesni_other.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+//go:build !amd64 || purego
+
+package aesni
+
+const haveAESNI = false
+
+func encryptBlocks(rounds int, xk, dst, src *byte, n int) {
+	panic("aesni: AES-NI not supported")
+}
@@ -0,0 +1,86 @@
+//
+// aesni_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package aesni
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		key := make([]byte, size)
+		rand.Read(key)
+
+		ref, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := New(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 0; n < 10; n++ {
+			src := make([]byte, n*BlockSize)
+			rand.Read(src)
+
+			expected := make([]byte, len(src))
+			for i := 0; i < len(src); i += BlockSize {
+				ref.Encrypt(expected[i:], src[i:])
+			}
+
+			dst := make([]byte, len(src))
+			c.Encrypt(dst, src)
+			if !bytes.Equal(dst, expected) {
+				t.Fatalf("AES-%d: %d blocks: got %x, expected %x",
+					size*8, n, dst, expected)
+			}
+
+			// In-place encryption.
+			c.Encrypt(src, src)
+			if !bytes.Equal(src, expected) {
+				t.Fatalf("AES-%d: %d blocks: in-place: got %x, expected %x",
+					size*8, n, src, expected)
+			}
+		}
+	}
+	if _, err := New(make([]byte, 20)); err == nil {
+		t.Errorf("invalid key size accepted")
+	}
+}
+
+func BenchmarkCipher(b *testing.B) {
+	var key [16]byte
+	c, err := New(key[:])
+	if err != nil {
+		b.Fatal(err)
+	}
+	var buf [4 * BlockSize]byte
+
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(buf[:], buf[:])
+	}
+}
+
+func BenchmarkStdlib(b *testing.B) {
+	var key [16]byte
+	c, err := aes.NewCipher(key[:])
+	if err != nil {
+		b.Fatal(err)
+	}
+	var buf [BlockSize]byte
+
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		c.Encrypt(buf[:], buf[:])
+	}
+}
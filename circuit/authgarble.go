@@ -0,0 +1,561 @@
+//
+// authgarble.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// authTableSize specifies the number of labels in the authenticated
+// garbled table of an AND gate: the MACs and labels of the four rows
+// and the encrypted mask bits of the rows.
+const authTableSize = 9
+
+// authCircuit holds our shares of the preprocessed circuit. The lambda
+// holds the masks of the wires and the sigma holds the products of
+// the input masks of the AND gates.
+type authCircuit struct {
+	lambda []authBit
+	sigma  []authBit
+}
+
+// checkAuthGarbling verifies that the authenticated garbling protocol
+// can run the circuit with the configuration.
+func (c *Circuit) checkAuthGarbling(cfg *utils.Config) error {
+	if cfg.OutputMode != utils.OutputDecode {
+		return fmt.Errorf("authenticated garbling needs output mode %v, "+
+			"got %v", utils.OutputDecode, cfg.OutputMode)
+	}
+	if cfg.Checkpoints != nil {
+		return fmt.Errorf("authenticated garbling sessions are not resumable")
+	}
+	if len(c.Inputs) != 2 {
+		return fmt.Errorf("authenticated garbling needs 2 parties, got %d",
+			len(c.Inputs))
+	}
+	for _, out := range c.Outputs {
+		if out.Share != ShareNone {
+			return fmt.Errorf("authenticated garbling can't share output %v",
+				out)
+		}
+	}
+	return nil
+}
+
+// authGarbling runs the authenticated garbling protocol of Wang,
+// Ranellucci, and Katz: "Authenticated Garbling and Efficient
+// Maliciously Secure Two-Party Computation", CCS 2017. The parties
+// authenticate random wire masks and AND triples with correlated OT
+// extension and information-theoretic MACs, and the garbler garbles
+// the circuit with the authenticated masks. The evaluator checks the
+// MACs of the garbled rows it decrypts so a malicious garbler can't
+// change the evaluated function, and the parties check the MACs of
+// all opened masks so neither party can change the inputs or outputs
+// of the peer.
+func authGarbling(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	party Party,
+	inputs *big.Int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	if err := circ.checkAuthGarbling(cfg); err != nil {
+		return nil, err
+	}
+	rand, err := sessionRandom(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	// A0. 握手: 确认协议版本, 电路和角色一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     party,
+		Instances: 1,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when exchanging handshake.")
+		return nil, err
+	}
+
+	// A1. 预处理: 认证的 wire masks 和 AND 三元组
+	if verbose {
+		fmt.Printf(" - Preprocessing circuit...\n")
+	}
+	p, err := newAuthParty(conn, oti.WithRandom(rand), rand, party)
+	if err != nil {
+		return nil, err
+	}
+	pre, err := p.preprocess(circ)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *big.Int
+	if party == PartyGarbler {
+		result, err = p.garbler(circ, pre, inputs, verbose)
+	} else {
+		result, err = p.evaluator(circ, pre, inputs, verbose)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A6. 确认通信记录
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when confirming transcript.")
+		return nil, err
+	}
+	return circ.Outputs.Hide(circ.Outputs.Split(result), party), nil
+}
+
+// andMasks returns the masks of the inputs and output of the AND or OR
+// gate. The OR gates are garbled as AND gates with complemented inputs
+// and output.
+func (p *authParty) andMasks(g Gate, lambda []authBit) (a, b, c authBit) {
+	a = lambda[g.Input0]
+	b = lambda[g.Input1]
+	c = lambda[g.Output]
+	if g.Op == OR {
+		a = p.xorConst(a, true)
+		b = p.xorConst(b, true)
+		c = p.xorConst(c, true)
+	}
+	return
+}
+
+// preprocess creates the authenticated masks of the circuit wires and
+// the products of the masks of the AND gate inputs. The input wires
+// and the AND gate outputs have random masks and the XOR, XNOR, and
+// INV gates compute their masks from their input masks. The products
+// are computed from the AND triples with Beaver's method.
+func (p *authParty) preprocess(circ *Circuit) (*authCircuit, error) {
+	var ands int
+	for _, g := range circ.Gates {
+		if g.Op == AND || g.Op == OR {
+			ands++
+		}
+	}
+	n := circ.Inputs.Size()
+	masks, err := p.randomBits(n + ands + tripleBits(ands))
+	if err != nil {
+		return nil, err
+	}
+	triples, err := p.triples(ands, masks[n+ands:])
+	if err != nil {
+		return nil, err
+	}
+
+	pre := &authCircuit{
+		lambda: make([]authBit, circ.NumWires),
+		sigma:  make([]authBit, ands),
+	}
+	copy(pre.lambda, masks[:n])
+	masks = masks[n : n+ands]
+
+	// B1. 打开 d = α ⊕ x 和 e = β ⊕ y.
+	var k int
+	de := make([]authBit, 0, 2*ands)
+	for _, g := range circ.Gates {
+		switch g.Op {
+		case XOR:
+			pre.lambda[g.Output] = pre.lambda[g.Input0].xor(pre.lambda[g.Input1])
+		case XNOR:
+			pre.lambda[g.Output] = p.xorConst(
+				pre.lambda[g.Input0].xor(pre.lambda[g.Input1]), true)
+		case INV:
+			pre.lambda[g.Output] = p.xorConst(pre.lambda[g.Input0], true)
+		case AND, OR:
+			pre.lambda[g.Output] = masks[k]
+			a, b, _ := p.andMasks(g, pre.lambda)
+			de = append(de, a.xor(triples[k].x), b.xor(triples[k].y))
+			k++
+		default:
+			return nil, fmt.Errorf("invalid gate type %s", g.Op)
+		}
+	}
+	opened, err := p.open(de, "beaver")
+	if err != nil {
+		return nil, err
+	}
+
+	// B2. αβ = z ⊕ d·y ⊕ e·x ⊕ d·e
+	for k, t := range triples {
+		d, e := opened[2*k], opened[2*k+1]
+		s := t.z.xor(t.y.scale(d)).xor(t.x.scale(e))
+		pre.sigma[k] = p.xorConst(s, d && e)
+	}
+	return pre, nil
+}
+
+// rowShare returns our share of the masked output of the AND gate row
+// (u, v) where u and v are the masked input values: σ ⊕ γ ⊕ u·β ⊕ v·α
+// ⊕ u·v.
+func (p *authParty) rowShare(sigma, a, b, c authBit, u, v bool) authBit {
+	s := sigma.xor(c).xor(b.scale(u)).xor(a.scale(v))
+	return p.xorConst(s, u && v)
+}
+
+// rowHash computes the pads of the garbled row of the AND gate k from
+// the row's input labels.
+func (p *authParty) rowHash(k, row int, a, b ot.Label) [3]ot.Label {
+	in := rowInput(a, b)
+	h := [3]ot.Label{in, in, in}
+	tweak := uint32(12*k + 3*row)
+	p.garble.Hash(h[:], []uint32{tweak, tweak + 1, tweak + 2})
+	return h
+}
+
+// authInputs holds the masked input values and their labels.
+type authInputs struct {
+	Values []bool
+	Labels []ot.Label
+}
+
+// garbler runs the garbler's online phase: it processes the inputs,
+// garbles and streams the circuit, and decodes the garbler's outputs.
+func (p *authParty) garbler(circ *Circuit, pre *authCircuit,
+	inputs *big.Int, verbose bool) (*big.Int, error) {
+
+	n0 := int(circ.Inputs[0].Type.Bits)
+	n1 := int(circ.Inputs[1].Type.Bits)
+	zeros := make([]ot.Label, circ.NumWires)
+	for i := 0; i < n0+n1; i++ {
+		l, err := ot.NewLabel(p.rand)
+		if err != nil {
+			return nil, err
+		}
+		zeros[i] = l
+	}
+	label := func(w int, z bool) ot.Label {
+		l := zeros[w]
+		if z {
+			l.Xor(p.delta)
+		}
+		return l
+	}
+
+	// G1. 发送我方 inputs 的 masked 值和 labels
+	masks, err := p.recvOpening(pre.lambda[:n0], "garbler input masks")
+	if err != nil {
+		return nil, err
+	}
+	var ours authInputs
+	for i, mask := range masks {
+		z := (inputs.Bit(i) == 1) != mask
+		ours.Values = append(ours.Values, z)
+		ours.Labels = append(ours.Labels, label(i, z))
+	}
+	if err := p.conn.DirectSend(&ours, "garbler inputs"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when sending garbler inputs.")
+		return nil, err
+	}
+
+	// G2. 打开评估方 inputs 的 masks, 发送 masked 值的 labels
+	err = p.sendOpening(pre.lambda[n0:n0+n1], "evaluator input masks")
+	if err != nil {
+		return nil, err
+	}
+	var values []bool
+	if err := p.conn.DirectRecv(&values, "evaluator inputs"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when receiving evaluator inputs.")
+		return nil, err
+	}
+	if len(values) != n1 {
+		return nil, fmt.Errorf("peer sent %d inputs, expected %d",
+			len(values), n1)
+	}
+	labels := make([]ot.Label, n1)
+	for i, z := range values {
+		labels[i] = label(n0+i, z)
+	}
+	if err := p.conn.DirectSend(labels, "evaluator input labels"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when sending input labels.")
+		return nil, err
+	}
+
+	// G3. 混淆并流式发送 gates
+	if verbose {
+		fmt.Printf(" - Garbling circuit...\n")
+	}
+	var k int
+	var chunk [][]ot.Label
+	for _, g := range circ.Gates {
+		switch g.Op {
+		case XOR, XNOR:
+			zeros[g.Output] = zeros[g.Input0]
+			zeros[g.Output].Xor(zeros[g.Input1])
+			continue
+		case INV:
+			zeros[g.Output] = zeros[g.Input0]
+			continue
+		}
+		l, err := ot.NewLabel(p.rand)
+		if err != nil {
+			return nil, err
+		}
+		zeros[g.Output] = l
+
+		a, b, c := p.andMasks(g, pre.lambda)
+		table := make([]ot.Label, authTableSize)
+		for row := 0; row < 4; row++ {
+			u, v := row&2 != 0, row&1 != 0
+			h := p.rowHash(k, row,
+				label(int(g.Input0), u), label(int(g.Input1), v))
+			s := p.rowShare(pre.sigma[k], a, b, c, u, v)
+
+			table[row] = s.mac
+			table[row].Xor(h[0])
+			table[4+row] = label(int(g.Output), s.bit)
+			table[4+row].Xor(s.key)
+			table[4+row].Xor(h[1])
+			if s.bit != h[2].S() {
+				table[8].D1 |= 1 << row
+			}
+		}
+		k++
+		chunk = append(chunk, table)
+		if len(chunk) >= gateChunkSize/authTableSize || k == len(pre.sigma) {
+			if err := p.conn.DirectSend(chunk, "garbled gates"); err != nil {
+				err = errors.Wrap(err,
+					"in mpc_hd::AuthGarbling(...), when sending garbled gates.")
+				return nil, err
+			}
+			chunk = nil
+		}
+	}
+
+	// G4. 打开评估方输出的 masks, 接收我方输出的 masks 和 labels
+	out := circ.NumWires - circ.Outputs.Size()
+	emask, gmask := p.outputMasks(circ, pre)
+	err = p.sendOpening(emask, "evaluator output masks")
+	if err != nil {
+		return nil, err
+	}
+	masks, err = p.recvOpening(gmask, "garbler output masks")
+	if err != nil {
+		return nil, err
+	}
+	var outputs []ot.Label
+	if err := p.conn.DirectRecv(&outputs, "garbler output labels"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when receiving output labels.")
+		return nil, err
+	}
+	if len(outputs) != len(masks) {
+		return nil, fmt.Errorf("peer sent %d output labels, expected %d",
+			len(outputs), len(masks))
+	}
+	result := new(big.Int)
+	var j int
+	for i, reveal := range circ.Outputs.Mask(PartyGarbler) {
+		if !reveal {
+			continue
+		}
+		var z bool
+		switch {
+		case outputs[j].Equal(label(out+i, false)):
+		case outputs[j].Equal(label(out+i, true)):
+			z = true
+		default:
+			return nil, ErrInvalidOutputLabel
+		}
+		if z != masks[j] {
+			result.SetBit(result, i, 1)
+		}
+		j++
+	}
+	return result, nil
+}
+
+// evaluator runs the evaluator's online phase: it processes the
+// inputs, evaluates the streamed circuit, and decodes the evaluator's
+// outputs.
+func (p *authParty) evaluator(circ *Circuit, pre *authCircuit,
+	inputs *big.Int, verbose bool) (*big.Int, error) {
+
+	n0 := int(circ.Inputs[0].Type.Bits)
+	n1 := int(circ.Inputs[1].Type.Bits)
+	values := make([]bool, circ.NumWires)
+	labels := make([]ot.Label, circ.NumWires)
+
+	// E1. 接收对方 inputs 的 masked 值和 labels
+	err := p.sendOpening(pre.lambda[:n0], "garbler input masks")
+	if err != nil {
+		return nil, err
+	}
+	var theirs authInputs
+	if err := p.conn.DirectRecv(&theirs, "garbler inputs"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when receiving garbler inputs.")
+		return nil, err
+	}
+	if len(theirs.Values) != n0 || len(theirs.Labels) != n0 {
+		return nil, fmt.Errorf("peer sent %d/%d inputs, expected %d",
+			len(theirs.Values), len(theirs.Labels), n0)
+	}
+	copy(values, theirs.Values)
+	copy(labels, theirs.Labels)
+
+	// E2. 发送我方 inputs 的 masked 值, 接收其 labels
+	masks, err := p.recvOpening(pre.lambda[n0:n0+n1], "evaluator input masks")
+	if err != nil {
+		return nil, err
+	}
+	for i, mask := range masks {
+		values[n0+i] = (inputs.Bit(i) == 1) != mask
+	}
+	err = p.conn.DirectSend(values[n0:n0+n1], "evaluator inputs")
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when sending evaluator inputs.")
+		return nil, err
+	}
+	var received []ot.Label
+	err = p.conn.DirectRecv(&received, "evaluator input labels")
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when receiving input labels.")
+		return nil, err
+	}
+	if len(received) != n1 {
+		return nil, fmt.Errorf("peer sent %d input labels, expected %d",
+			len(received), n1)
+	}
+	copy(labels[n0:], received)
+
+	// E3. 逐块接收并评估 gates, 验证解密行的 MAC.
+	if verbose {
+		fmt.Printf(" - Evaluating circuit...\n")
+	}
+	var k int
+	var chunk [][]ot.Label
+	for _, g := range circ.Gates {
+		switch g.Op {
+		case XOR, XNOR:
+			values[g.Output] = values[g.Input0] != values[g.Input1]
+			labels[g.Output] = labels[g.Input0]
+			labels[g.Output].Xor(labels[g.Input1])
+			continue
+		case INV:
+			values[g.Output] = values[g.Input0]
+			labels[g.Output] = labels[g.Input0]
+			continue
+		}
+		if len(chunk) == 0 {
+			if err := p.conn.DirectRecv(&chunk, "garbled gates"); err != nil {
+				err = errors.Wrap(err,
+					"in mpc_hd::AuthGarbling(...), when receiving garbled gates.")
+				return nil, err
+			}
+			if len(chunk) == 0 {
+				return nil, fmt.Errorf("empty garbled gates chunk")
+			}
+		}
+		table := chunk[0]
+		chunk = chunk[1:]
+		if len(table) != authTableSize {
+			return nil, fmt.Errorf("corrupted circuit: %s table length: %d",
+				g.Op, len(table))
+		}
+
+		a, b, c := p.andMasks(g, pre.lambda)
+		u, v := values[g.Input0], values[g.Input1]
+		row := 2*bit(u) + bit(v)
+		h := p.rowHash(k, row, labels[g.Input0], labels[g.Input1])
+		s := p.rowShare(pre.sigma[k], a, b, c, u, v)
+
+		mac := table[row]
+		mac.Xor(h[0])
+		r := (table[8].D1>>row)&1 != 0 != h[2].S()
+		expected := s.key
+		if r {
+			expected.Xor(p.delta)
+		}
+		if !mac.Equal(expected) {
+			return nil, ErrAuthenticationFailed
+		}
+		values[g.Output] = r != s.bit
+		labels[g.Output] = table[4+row]
+		labels[g.Output].Xor(h[1])
+		labels[g.Output].Xor(s.mac)
+		k++
+	}
+	if len(chunk) != 0 {
+		return nil, fmt.Errorf("peer sent %d extra garbled gates", len(chunk))
+	}
+
+	// E4. 接收我方输出的 masks, 打开对方输出的 masks 并发送其 labels
+	out := circ.NumWires - circ.Outputs.Size()
+	emask, gmask := p.outputMasks(circ, pre)
+	masks, err = p.recvOpening(emask, "evaluator output masks")
+	if err != nil {
+		return nil, err
+	}
+	result := new(big.Int)
+	var j int
+	for i, reveal := range circ.Outputs.Mask(PartyEvaluator) {
+		if !reveal {
+			continue
+		}
+		if values[out+i] != masks[j] {
+			result.SetBit(result, i, 1)
+		}
+		j++
+	}
+	err = p.sendOpening(gmask, "garbler output masks")
+	if err != nil {
+		return nil, err
+	}
+	received = nil
+	for i, reveal := range circ.Outputs.Mask(PartyGarbler) {
+		if reveal {
+			received = append(received, labels[out+i])
+		}
+	}
+	err = p.conn.DirectSend(received, "garbler output labels")
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when sending output labels.")
+		return nil, err
+	}
+	return result, nil
+}
+
+// outputMasks returns the masks of the output wires that are revealed
+// to the evaluator and to the garbler.
+func (p *authParty) outputMasks(circ *Circuit, pre *authCircuit) (
+	evaluator, garbler []authBit) {
+
+	out := circ.NumWires - circ.Outputs.Size()
+	emask := circ.Outputs.Mask(PartyEvaluator)
+	gmask := circ.Outputs.Mask(PartyGarbler)
+	for i := range emask {
+		if emask[i] {
+			evaluator = append(evaluator, pre.lambda[out+i])
+		}
+		if gmask[i] {
+			garbler = append(garbler, pre.lambda[out+i])
+		}
+	}
+	return
+}
@@ -0,0 +1,257 @@
+//
+// checkpoint.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/ot/drbg"
+)
+
+// ErrCheckpointMismatch is returned if the resumed session does not
+// match the session checkpoint.
+var ErrCheckpointMismatch = errors.New("session does not match checkpoint")
+
+// Checkpoint records the protocol state of a resumable session. The
+// session randomness is derived from the DRBG seed so a resumed session
+// re-creates the garbled circuit, output masks, and OT messages of
+// the original session. The messages delivered before the restart are
+// re-read from the messenger.
+type Checkpoint struct {
+	Party   Party
+	Circuit []byte
+	DRBG    drbg.Algorithm
+	Seed    []byte
+	Step    int
+	NSend   int
+	NRecv   int
+}
+
+// session implements the checkpointing of a resumable session. The
+// nil session is not resumable and its operations do nothing.
+type session struct {
+	store   utils.CheckpointStore
+	id      string
+	conn    *ot.Conn
+	cp      Checkpoint
+	resumed *Checkpoint
+}
+
+// newSession loads or creates the checkpoint of the party's session.
+// The function returns the session and the source of randomness for
+// the protocol run.
+func newSession(cfg *utils.Config, conn *ot.Conn, circ *Circuit,
+	party Party) (*session, io.Reader, error) {
+
+	if cfg.Checkpoints == nil {
+		r, err := sessionRandom(cfg, conn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, r, nil
+	}
+	digest, err := circ.Digest()
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &session{
+		store: cfg.Checkpoints,
+		id:    fmt.Sprintf("%s/%s", conn.SessionId(), party),
+		conn:  conn,
+	}
+	data, err := s.store.Load(s.id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data != nil {
+		s.resumed = new(Checkpoint)
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(s.resumed)
+		if err != nil {
+			return nil, nil, err
+		}
+		if s.resumed.Party != party ||
+			!bytes.Equal(s.resumed.Circuit, digest) {
+			return nil, nil, ErrCheckpointMismatch
+		}
+		s.cp = *s.resumed
+		conn.SetResume(true)
+	} else {
+		r, err := cfg.SessionRandom()
+		if err != nil {
+			return nil, nil, err
+		}
+		s.cp = Checkpoint{
+			Party:   party,
+			Circuit: digest,
+			DRBG:    r.Algorithm(),
+			Seed:    r.Seed(),
+			Step:    -1,
+		}
+		if err := s.save(); err != nil {
+			return nil, nil, err
+		}
+	}
+	r, err := drbg.New(s.cp.DRBG, s.cp.Seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err = conn.SessionDRBG(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, r, nil
+}
+
+// sessionRandom creates the session DRBG of the protocol run on the
+// connection. The recording connection records the DRBG and the
+// replaying connection returns the recorded DRBG.
+func sessionRandom(cfg *utils.Config, conn *ot.Conn) (*drbg.DRBG, error) {
+	r, err := cfg.SessionRandom()
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return r, nil
+	}
+	return conn.SessionDRBG(r)
+}
+
+// step records the completion of the protocol step. If the step was
+// completed before the restart, the function verifies that the replay
+// has exchanged the same number of messages.
+func (s *session) step(n int) error {
+	if s == nil {
+		return nil
+	}
+	nsend, nrecv := s.conn.Counters()
+	if s.resumed != nil && n <= s.resumed.Step {
+		if n == s.resumed.Step &&
+			(nsend != s.resumed.NSend || nrecv != s.resumed.NRecv) {
+			return ErrCheckpointMismatch
+		}
+		return nil
+	}
+	s.cp.Step = n
+	s.cp.NSend = nsend
+	s.cp.NRecv = nrecv
+	return s.save()
+}
+
+// done removes the checkpoint of the completed session.
+func (s *session) done() error {
+	if s == nil {
+		return nil
+	}
+	return s.store.Delete(s.id)
+}
+
+func (s *session) save() error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&s.cp); err != nil {
+		return err
+	}
+	return s.store.Save(s.id, buf.Bytes())
+}
+
+// FileCheckpoints implements a checkpoint store in a local directory.
+// The checkpoints are sealed with AES-GCM under the store key.
+type FileCheckpoints struct {
+	dir  string
+	aead cipher.AEAD
+}
+
+// NewFileCheckpoints creates a checkpoint store in the directory. The
+// key must be 16, 24, or 32 bytes long.
+func NewFileCheckpoints(dir string, key []byte) (*FileCheckpoints, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileCheckpoints{
+		dir:  dir,
+		aead: aead,
+	}, nil
+}
+
+func (f *FileCheckpoints) path(id string) string {
+	h := sha256.Sum256([]byte(id))
+	return filepath.Join(f.dir, hex.EncodeToString(h[:16])+".ckpt")
+}
+
+// Load implements utils.CheckpointStore.Load.
+func (f *FileCheckpoints) Load(id string) ([]byte, error) {
+	sealed, err := os.ReadFile(f.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	size := f.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, fmt.Errorf("checkpoint %s: truncated file", id)
+	}
+	data, err := f.aead.Open(nil, sealed[:size], sealed[size:], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %v", id, err)
+	}
+	return data, nil
+}
+
+// Save implements utils.CheckpointStore.Save.
+func (f *FileCheckpoints) Save(id string, data []byte) error {
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := f.aead.Seal(nonce, nonce, data, []byte(id))
+
+	tmp, err := os.CreateTemp(f.dir, "ckpt-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(sealed)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(id))
+}
+
+// Delete implements utils.CheckpointStore.Delete.
+func (f *FileCheckpoints) Delete(id string) error {
+	err := os.Remove(f.path(id))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 
 	"github.com/markkurossi/tabulate"
 )
@@ -62,9 +63,25 @@ func (stats Stats) Count() uint64 {
 	return result
 }
 
-// Cost computes the relative computational cost of the circuit.
+// Cost computes the relative computational cost of the circuit as the
+// number of garbled labels with the half-gates scheme.
 func (stats Stats) Cost() uint64 {
-	return (stats[AND]+stats[INV])*2 + stats[OR]*3
+	var gs halfGates
+	var result uint64
+	for op := XOR; op < Count; op++ {
+		result += stats[op] * uint64(gs.TableSize(op))
+	}
+	return result
+}
+
+// CostFor computes the number of bytes the garbled tables of the
+// circuit take on the wire with the garbling scheme.
+func (stats Stats) CostFor(gs GarblingScheme) uint64 {
+	var result uint64
+	for op := XOR; op < Count; op++ {
+		result += stats[op] * uint64(gs.TableBytes(op))
+	}
+	return result
 }
 
 func (stats Stats) String() string {
@@ -111,6 +128,11 @@ type Circuit struct {
 	Outputs  IO
 	Gates    []Gate
 	Stats    Stats
+
+	// Public holds the values of the public main arguments the
+	// circuit was compiled with. The peers verify in the protocol
+	// handshake that they use the same values.
+	Public map[string]*big.Int
 }
 
 func (c *Circuit) String() string {
@@ -173,6 +195,12 @@ func (c *Circuit) Cost() uint64 {
 	return c.Stats.Cost()
 }
 
+// CostFor computes the number of bytes the garbled tables of the
+// circuit take on the wire with the garbling scheme.
+func (c *Circuit) CostFor(gs GarblingScheme) uint64 {
+	return c.Stats.CostFor(gs)
+}
+
 // Dump prints a debug dump of the circuit.
 func (c *Circuit) Dump() {
 	fmt.Printf("circuit %s\n", c)
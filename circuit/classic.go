@@ -0,0 +1,155 @@
+//
+// classic.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"crypto/cipher"
+	"fmt"
+
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// classic implements the four-row garbling of Yao's garbled circuits
+// with point-and-permute and free XOR. The garbled table of the AND
+// and OR gates encrypts the output label of each input combination.
+// The rows are ordered by the permute bits of the input labels so the
+// evaluator decrypts exactly one row.
+type classic struct {
+	mmoHash
+	out cipher.Block
+}
+
+// ID implements GarblingScheme.ID.
+func (s *classic) ID() utils.Garbling {
+	return utils.Classic
+}
+
+// TableSize implements GarblingScheme.TableSize.
+func (s *classic) TableSize(op Operation) int {
+	switch op {
+	case AND, OR:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// TableBytes implements GarblingScheme.TableBytes.
+func (s *classic) TableBytes(op Operation) int {
+	return s.TableSize(op) * 16
+}
+
+// Tweaks implements GarblingScheme.Tweaks.
+func (s *classic) Tweaks(op Operation) uint32 {
+	switch op {
+	case AND, OR:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// rowInput combines the input labels a and b of the row into the
+// hash input a ⊕ 2b.
+func rowInput(a, b ot.Label) ot.Label {
+	b.Mul2()
+	a.Xor(b)
+	return a
+}
+
+// Garble implements GarblingScheme.Garble.
+func (s *classic) Garble(g *Gate, wires []ot.Wire, r ot.Label,
+	tweak uint32, data *ot.LabelData) ([]ot.Label, error) {
+
+	if garbleFree(g, wires, r) {
+		return nil, nil
+	}
+	if g.Op != AND && g.Op != OR {
+		return nil, fmt.Errorf("invalid gate type %s", g.Op)
+	}
+	a := wires[g.Input0]
+	b := wires[g.Input1]
+
+	// The output labels are derived from the gate tweak with the
+	// secret cipher.
+	ot.NewTweak(tweak).GetData(data)
+	s.out.Encrypt(data[:], data[:])
+	var c0 ot.Label
+	c0.SetData(data)
+	c1 := c0
+	c1.Xor(r)
+
+	var h [4]ot.Label
+	var idx [4]int
+	for x := 0; x < 2; x++ {
+		la := a.L0
+		if x == 1 {
+			la = a.L1
+		}
+		for y := 0; y < 2; y++ {
+			lb := b.L0
+			if y == 1 {
+				lb = b.L1
+			}
+			h[2*x+y] = rowInput(la, lb)
+			idx[2*x+y] = 2*bit(la.S()) + bit(lb.S())
+		}
+	}
+	s.Hash(h[:], []uint32{tweak, tweak, tweak, tweak})
+
+	table := make([]ot.Label, 4)
+	for i := range h {
+		x, y := i>>1, i&1
+		var z bool
+		if g.Op == AND {
+			z = x&y == 1
+		} else {
+			z = x|y == 1
+		}
+		c := c0
+		if z {
+			c = c1
+		}
+		c.Xor(h[i])
+		table[idx[i]] = c
+	}
+	wires[g.Output] = ot.Wire{
+		L0: c0,
+		L1: c1,
+	}
+	return table, nil
+}
+
+// Eval implements GarblingScheme.Eval.
+func (s *classic) Eval(g *Gate, wires []ot.Label, row []ot.Label,
+	tweak uint32, data *ot.LabelData) error {
+
+	if len(row) != s.TableSize(g.Op) {
+		return fmt.Errorf("corrupted ciruit: %s row length: %d",
+			g.Op, len(row))
+	}
+	if evalFree(g, wires) {
+		return nil
+	}
+	if g.Op != AND && g.Op != OR {
+		return fmt.Errorf("invalid operation %s", g.Op)
+	}
+	a := wires[g.Input0]
+	b := wires[g.Input1]
+
+	h := [1]ot.Label{rowInput(a, b)}
+	s.Hash(h[:], []uint32{tweak})
+
+	c := row[2*bit(a.S())+bit(b.S())]
+	c.Xor(h[0])
+	wires[g.Output] = c
+
+	return nil
+}
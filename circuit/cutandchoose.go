@@ -0,0 +1,904 @@
+//
+// cutandchoose.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"math/bits"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/types"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/ot/drbg"
+)
+
+// ErrCheatingDetected is returned when the evaluator detects that the
+// garbler deviated from the cut-and-choose protocol.
+var ErrCheatingDetected = errors.New("garbler cheating detected")
+
+const (
+	phaseCutAndChoose = "cut-and-choose"
+
+	// ccInputShares specifies the number of random XOR shares each
+	// evaluator input bit is split into. The shares make the
+	// evaluator's aborts independent of its inputs if the garbler
+	// sends invalid input labels in the OT.
+	ccInputShares = 40
+
+	// ccHashBits specifies the size of the universal hash that checks
+	// the consistency of the garbler's inputs.
+	ccHashBits = 40
+
+	// ccPadBits specifies the number of garbler's random pad bits
+	// that hide the garbler's inputs in the universal hash.
+	ccPadBits = 2 * ccHashBits
+)
+
+// GarblerCutAndChoose runs the garbler of the cut-and-choose
+// protocol. The garbler garbles the argument number of circuit
+// instances from random seeds and commits to them. The evaluator
+// opens a random half of the instances and verifies that they are
+// garbled correctly. The evaluator evaluates the remaining instances
+// and takes the majority output. The protocol protects the evaluator
+// against a malicious garbler. The circuit outputs must be revealed
+// to the evaluator, and the garbler gets the outputs revealed to both
+// parties from the evaluator.
+func GarblerCutAndChoose(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs *big.Int,
+	instances int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	result, err := garblerCutAndChoose(cfg, conn, oti, circ, inputs,
+		instances, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return result, err
+}
+
+// EvaluatorCutAndChoose runs the evaluator of the cut-and-choose
+// protocol. The garbler must run GarblerCutAndChoose with the same
+// number of instances. The function returns ErrCheatingDetected if
+// the garbler deviated from the protocol.
+func EvaluatorCutAndChoose(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs *big.Int,
+	instances int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	result, err := evaluatorCutAndChoose(cfg, conn, oti, circ, inputs,
+		instances, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return result, err
+}
+
+// ccCommitment holds the garbler's commitments to the garbled
+// instances and to its input labels of the instances.
+type ccCommitment struct {
+	Digests [][]byte
+	Inputs  [][]byte
+}
+
+// ccOpening opens the seeds of the check instances, and the keys and
+// garbler's input labels of the evaluation instances.
+type ccOpening struct {
+	Seeds  [][]byte
+	Keys   [][32]byte
+	Nonces [][]byte
+	Inputs [][]ot.Label
+}
+
+// ccInstance holds a circuit instance garbled from a seed. The pad
+// wires hold the labels of the garbler's pad inputs of the input
+// consistency check.
+type ccInstance struct {
+	key     [32]byte
+	garbled *Garbled
+	pad     []ot.Wire
+	digest  []byte
+}
+
+// checkCutAndChoose verifies that the cut-and-choose protocol can run
+// the circuit with the configuration and number of instances.
+func (c *Circuit) checkCutAndChoose(cfg *utils.Config, instances int) error {
+	if cfg.OutputMode != utils.OutputDecode {
+		return fmt.Errorf("cut-and-choose needs output mode %v, got %v",
+			utils.OutputDecode, cfg.OutputMode)
+	}
+	if cfg.Checkpoints != nil {
+		return fmt.Errorf("cut-and-choose sessions are not resumable")
+	}
+	if instances < 2 {
+		return fmt.Errorf("cut-and-choose needs at least 2 instances, got %d",
+			instances)
+	}
+	if len(c.Inputs) != 2 {
+		return fmt.Errorf("cut-and-choose needs 2 parties, got %d",
+			len(c.Inputs))
+	}
+	for _, out := range c.Outputs {
+		if !out.RevealedTo(PartyEvaluator) {
+			return fmt.Errorf("cut-and-choose can't hide output %v "+
+				"from the evaluator", out)
+		}
+	}
+	return nil
+}
+
+// garbleSeed garbles the circuit from the seed. The instance key, the
+// wire labels, and the pad labels are drawn from a DRBG seeded with
+// the seed, and the gates are garbled like Circuit.Garble garbles them
+// with the configured garbling scheme.
+func (c *Circuit) garbleSeed(cfg *utils.Config, seed []byte) (
+	*ccInstance, error) {
+
+	r, err := drbg.New(drbg.AESCTR, seed)
+	if err != nil {
+		return nil, err
+	}
+	inst := new(ccInstance)
+	if _, err := io.ReadFull(r, inst.key[:]); err != nil {
+		return nil, err
+	}
+	inst.garbled, err = c.garble(r, inst.key[:], cfg.Garbling, cfg.Workers)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < ccPadBits; i++ {
+		w, err := makeLabels(r, inst.garbled.R)
+		if err != nil {
+			return nil, err
+		}
+		inst.pad = append(inst.pad, w)
+	}
+	d := newCCDigest(inst.key)
+	d.gates(inst.garbled.Gates)
+	d.decoding(inst.garbled.OutputDecoding(c))
+	inst.digest = d.sum()
+
+	return inst, nil
+}
+
+// garblerInputs returns the garbler's input wires and the pad wires
+// of the instance.
+func (inst *ccInstance) garblerInputs(c *Circuit) []ot.Wire {
+	n0 := int(c.Inputs[0].Type.Bits)
+	wires := make([]ot.Wire, 0, n0+ccPadBits)
+	wires = append(wires, inst.garbled.Wires[:n0]...)
+	return append(wires, inst.pad...)
+}
+
+// ccDigest computes the digest of a garbled instance over its key,
+// garbled gates, and output decoding.
+type ccDigest struct {
+	h    hash.Hash
+	data ot.LabelData
+}
+
+func newCCDigest(key [32]byte) *ccDigest {
+	d := &ccDigest{
+		h: sha256.New(),
+	}
+	d.h.Write(key[:])
+	return d
+}
+
+func (d *ccDigest) gates(gates [][]ot.Label) {
+	var buf [4]byte
+	for _, gate := range gates {
+		binary.BigEndian.PutUint32(buf[:], uint32(len(gate)))
+		d.h.Write(buf[:])
+		for _, l := range gate {
+			l.GetData(&d.data)
+			d.h.Write(d.data[:])
+		}
+	}
+}
+
+func (d *ccDigest) decoding(decoding []OutputDecoding) {
+	for _, dec := range decoding {
+		d.h.Write(dec.H0[:])
+		d.h.Write(dec.H1[:])
+	}
+}
+
+func (d *ccDigest) sum() []byte {
+	return d.h.Sum(nil)
+}
+
+// ccInputCommitment commits to the garbler's input labels with the
+// nonce.
+func ccInputCommitment(nonce []byte, labels []ot.Label) []byte {
+	var data ot.LabelData
+	h := sha256.New()
+	h.Write(nonce)
+	for _, l := range labels {
+		l.GetData(&data)
+		h.Write(data[:])
+	}
+	return h.Sum(nil)
+}
+
+// ccHashMatrix creates the matrix of the universal hash from the seed.
+// The matrix has ccHashBits rows of n bits.
+func ccHashMatrix(seed []byte, n int) ([]*big.Int, error) {
+	r, err := drbg.New(drbg.AESCTR, seed)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, (n+7)/8)
+	m := make([]*big.Int, ccHashBits)
+	for i := range m {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		m[i] = new(big.Int).SetBytes(buf)
+		for j := n; j < len(buf)*8; j++ {
+			m[i].SetBit(m[i], j, 0)
+		}
+	}
+	return m, nil
+}
+
+// ccHash computes the universal hash of the permute bits of the
+// labels. The hash of the garbler's input labels, XORed with the hash
+// of the 0-labels, is the hash of the garbler's inputs.
+func ccHash(m []*big.Int, labels []ot.Label) uint64 {
+	x := new(big.Int)
+	for i, l := range labels {
+		if l.S() {
+			x.SetBit(x, i, 1)
+		}
+	}
+	var result uint64
+	var t big.Int
+	for row, r := range m {
+		t.And(r, x)
+		var ones int
+		for _, w := range t.Bits() {
+			ones += bits.OnesCount(uint(w))
+		}
+		result |= uint64(ones&1) << row
+	}
+	return result
+}
+
+// shareInputs returns a copy of the two-party circuit where each
+// evaluator input bit is split into n XOR shares. The circuit
+// recombines the input bits from the shares with XOR gates.
+func (c *Circuit) shareInputs(n int) *Circuit {
+	n0 := Wire(c.Inputs[0].Type.Bits)
+	n1 := Wire(c.Inputs[1].Type.Bits)
+	inputs := n0 + n1*Wire(n)
+
+	// The XOR gates of the shares follow the input wires and the
+	// circuit's gates follow the XOR gates.
+	b := &shareBuilder{
+		next: inputs,
+	}
+	bits := make([]Wire, n1)
+	for i := Wire(0); i < n1; i++ {
+		w := n0 + i*Wire(n)
+		for j := Wire(1); j < Wire(n); j++ {
+			w = b.gate(XOR, w, n0+i*Wire(n)+j)
+		}
+		bits[i] = w
+	}
+	shift := b.next - (n0 + n1)
+	remap := func(w Wire) Wire {
+		switch {
+		case w < n0:
+			return w
+		case w < n0+n1:
+			return bits[w-n0]
+		default:
+			return w + shift
+		}
+	}
+	for _, g := range c.Gates {
+		g.Input0 = remap(g.Input0)
+		if g.Op != INV {
+			g.Input1 = remap(g.Input1)
+		}
+		g.Output = remap(g.Output)
+		b.gates = append(b.gates, g)
+	}
+
+	stats := c.Stats
+	stats[XOR] += uint64(n1) * uint64(n-1)
+
+	return &Circuit{
+		NumGates: len(b.gates),
+		NumWires: c.NumWires + int(shift),
+		Inputs: IO{
+			c.Inputs[0],
+			IOArg{
+				Name: c.Inputs[1].Name,
+				Type: types.Info{
+					Type:       types.TUint,
+					IsConcrete: true,
+					Bits:       c.Inputs[1].Type.Bits * types.Size(n),
+				},
+			},
+		},
+		Outputs: c.Outputs,
+		Gates:   b.gates,
+		Stats:   stats,
+	}
+}
+
+// shareInput splits the evaluator's input into n random XOR shares
+// per input bit, matching the inputs of the shareInputs circuit.
+func shareInput(r io.Reader, input *big.Int, bits, n int) (*big.Int, error) {
+	buf := make([]byte, (bits*n+7)/8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	shares := new(big.Int).SetBytes(buf)
+	for i := 0; i < bits; i++ {
+		bit := input.Bit(i)
+		for j := 0; j < n-1; j++ {
+			bit ^= shares.Bit(i*n + j)
+		}
+		shares.SetBit(shares, i*n+n-1, bit)
+	}
+	for i := bits * n; i < len(buf)*8; i++ {
+		shares.SetBit(shares, i, 0)
+	}
+	return shares, nil
+}
+
+func garblerCutAndChoose(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs *big.Int,
+	instances int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	if err := circ.checkCutAndChoose(cfg, instances); err != nil {
+		return nil, err
+	}
+	rand, err := sessionRandom(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+	oti = oti.WithRandom(rand)
+
+	// C0. 握手: 确认协议版本, 电路和实例数一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyGarbler,
+		Instances: instances,
+		Phase:     phaseCutAndChoose,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when exchanging handshake.")
+		return nil, err
+	}
+	shared := circ.shareInputs(ccInputShares)
+	n0 := int(shared.Inputs[0].Type.Bits)
+	n1 := int(shared.Inputs[1].Type.Bits)
+
+	var pad [ccPadBits / 8]byte
+	if _, err := io.ReadFull(rand, pad[:]); err != nil {
+		return nil, err
+	}
+	value := new(big.Int).SetBytes(pad[:])
+	value.Lsh(value, uint(n0))
+	value.Or(value, inputs)
+
+	// C1. 从种子混淆所有实例, 承诺实例和我方输入 labels
+	if verbose {
+		fmt.Printf(" - Garbling %d instance(s)...\n", instances)
+	}
+	seeds := make([][]byte, instances)
+	keys := make([][32]byte, instances)
+	nonces := make([][]byte, instances)
+	labels := make([][]ot.Label, instances)
+	zeros := make([][]ot.Label, instances)
+	var otWires []ot.Wire
+	var commitment ccCommitment
+	for i := 0; i < instances; i++ {
+		seeds[i] = make([]byte, drbg.SeedSize)
+		nonces[i] = make([]byte, 32)
+		if _, err := io.ReadFull(rand, seeds[i]); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(rand, nonces[i]); err != nil {
+			return nil, err
+		}
+		inst, err := shared.garbleSeed(cfg, seeds[i])
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = inst.key
+		for bit, wire := range inst.garblerInputs(shared) {
+			labels[i] = append(labels[i],
+				LabelForBit(wire, value.Bit(bit) == 1))
+			zeros[i] = append(zeros[i], wire.L0)
+		}
+		otWires = append(otWires, inst.garbled.Wires[n0:n0+n1]...)
+		commitment.Digests = append(commitment.Digests, inst.digest)
+		commitment.Inputs = append(commitment.Inputs,
+			ccInputCommitment(nonces[i], labels[i]))
+	}
+	if err := conn.DirectSend(commitment, "commitments"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when sending commitments.")
+		return nil, err
+	}
+
+	// C2. 执行 ot 发送. 评估方接收所有实例的输入 labels.
+	if err := oti.Send(otWires, conn); err != nil {
+		return nil, err
+	}
+
+	// C3. 接收通用哈希种子
+	var hashSeed []byte
+	if err := conn.DirectRecv(&hashSeed, "hash seed"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when receiving hash seed.")
+		return nil, err
+	}
+	m, err := ccHashMatrix(hashSeed, n0+ccPadBits)
+	if err != nil {
+		return nil, err
+	}
+
+	// C4. 发送 0-labels 的哈希
+	decoding := make([]uint64, instances)
+	for i := range decoding {
+		decoding[i] = ccHash(m, zeros[i])
+	}
+	if err := conn.DirectSend(decoding, "hash decoding"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when sending hash decoding.")
+		return nil, err
+	}
+
+	// C5. 接收检查集合
+	var check []bool
+	if err := conn.DirectRecv(&check, "check set"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when receiving check set.")
+		return nil, err
+	}
+	if len(check) != instances {
+		return nil, fmt.Errorf("peer sent check set of %d instances, "+
+			"expected %d", len(check), instances)
+	}
+	var count int
+	for _, c := range check {
+		if c {
+			count++
+		}
+	}
+	if count != instances/2 {
+		return nil, fmt.Errorf("peer checks %d instances, expected %d",
+			count, instances/2)
+	}
+
+	// C6. 打开检查实例的种子, 评估实例的密钥和输入 labels
+	opening := ccOpening{
+		Seeds:  make([][]byte, instances),
+		Keys:   make([][32]byte, instances),
+		Nonces: make([][]byte, instances),
+		Inputs: make([][]ot.Label, instances),
+	}
+	for i := range check {
+		if check[i] {
+			opening.Seeds[i] = seeds[i]
+		} else {
+			opening.Keys[i] = keys[i]
+			opening.Nonces[i] = nonces[i]
+			opening.Inputs[i] = labels[i]
+		}
+	}
+	if err := conn.DirectSend(opening, "openings"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when sending openings.")
+		return nil, err
+	}
+
+	// C7. 从种子重新混淆评估实例, 逐块发送 gates 和输出解码信息
+	if verbose {
+		fmt.Printf(" - Sending %d instance(s)...\n", instances-count)
+	}
+	for i := range check {
+		if check[i] {
+			continue
+		}
+		inst, err := shared.garbleSeed(cfg, seeds[i])
+		if err != nil {
+			return nil, err
+		}
+		gs, err := NewGarblingScheme(cfg.Garbling, inst.key[:], nil)
+		if err != nil {
+			return nil, err
+		}
+		gates := inst.garbled.Gates
+		for from := 0; from < len(gates); from += gateChunkSize {
+			n := min(len(gates)-from, gateChunkSize)
+			chunk, err := encodeTables(gs, shared.Gates[from:],
+				gates[from:from+n])
+			if err != nil {
+				return nil, err
+			}
+			if err := conn.DirectSend(chunk, "garbled gates"); err != nil {
+				err = errors.Wrap(err,
+					"in mpc_hd::GarblerCutAndChoose(...), when sending garbled gates.")
+				return nil, err
+			}
+		}
+		err = conn.DirectSend(inst.garbled.OutputDecoding(shared),
+			"output decoding")
+		if err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::GarblerCutAndChoose(...), when sending output decoding.")
+			return nil, err
+		}
+	}
+
+	// C8. 接收多数结果
+	var result *big.Int
+	if err := conn.DirectRecv(&result, "result"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when receiving result.")
+		return nil, err
+	}
+
+	// C9. 确认通信记录
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerCutAndChoose(...), when confirming transcript")
+		return nil, err
+	}
+	values := circ.Outputs.Split(result)
+	return circ.Outputs.Hide(values, PartyGarbler), nil
+}
+
+func evaluatorCutAndChoose(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs *big.Int,
+	instances int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	if err := circ.checkCutAndChoose(cfg, instances); err != nil {
+		return nil, err
+	}
+	r, err := sessionRandom(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+	oti = oti.WithRandom(r)
+
+	// C0. 握手: 确认协议版本, 电路和实例数一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyEvaluator,
+		Instances: instances,
+		Phase:     phaseCutAndChoose,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when exchanging handshake.")
+		return nil, err
+	}
+	shared := circ.shareInputs(ccInputShares)
+	n0 := int(shared.Inputs[0].Type.Bits)
+	n1 := int(shared.Inputs[1].Type.Bits)
+
+	// C1. 接收实例和输入 labels 的承诺
+	if verbose {
+		fmt.Printf(" - Waiting for commitments...\n")
+	}
+	var commitment ccCommitment
+	if err := conn.DirectRecv(&commitment, "commitments"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when receiving commitments.")
+		return nil, err
+	}
+	if len(commitment.Digests) != instances ||
+		len(commitment.Inputs) != instances {
+		return nil, fmt.Errorf("peer sent commitments for %d instances, "+
+			"expected %d", len(commitment.Digests), instances)
+	}
+
+	// C2. 执行 ot 接收. 所有实例使用相同的输入分享.
+	shares, err := shareInput(r, inputs, int(circ.Inputs[1].Type.Bits),
+		ccInputShares)
+	if err != nil {
+		return nil, err
+	}
+	var flags []bool
+	for i := 0; i < instances; i++ {
+		for bit := 0; bit < n1; bit++ {
+			flags = append(flags, shares.Bit(bit) == 1)
+		}
+	}
+	received := make([]ot.Label, len(flags))
+	if err := oti.Receive(flags, received, conn); err != nil {
+		return nil, err
+	}
+
+	// C3. 发送通用哈希种子. 混淆方已承诺其输入.
+	hashSeed := make([]byte, drbg.SeedSize)
+	if _, err := io.ReadFull(r, hashSeed); err != nil {
+		return nil, err
+	}
+	if err := conn.DirectSend(hashSeed, "hash seed"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when sending hash seed.")
+		return nil, err
+	}
+	m, err := ccHashMatrix(hashSeed, n0+ccPadBits)
+	if err != nil {
+		return nil, err
+	}
+
+	// C4. 接收 0-labels 的哈希
+	var decoding []uint64
+	if err := conn.DirectRecv(&decoding, "hash decoding"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when receiving hash decoding.")
+		return nil, err
+	}
+	if len(decoding) != instances {
+		return nil, fmt.Errorf("peer sent hash decoding for %d instances, "+
+			"expected %d", len(decoding), instances)
+	}
+
+	// C5. 发送随机检查集合
+	check := make([]bool, instances)
+	perm := make([]int, instances)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := instances - 1; i > 0; i-- {
+		j, err := rand.Int(r, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		perm[i], perm[j.Int64()] = perm[j.Int64()], perm[i]
+	}
+	for _, i := range perm[:instances/2] {
+		check[i] = true
+	}
+	if err := conn.DirectSend(check, "check set"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when sending check set.")
+		return nil, err
+	}
+
+	// C6. 接收打开信息, 验证检查实例
+	var opening ccOpening
+	if err := conn.DirectRecv(&opening, "openings"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when receiving openings.")
+		return nil, err
+	}
+	if len(opening.Seeds) != instances || len(opening.Keys) != instances ||
+		len(opening.Nonces) != instances || len(opening.Inputs) != instances {
+		return nil, fmt.Errorf("peer sent openings for %d instances, "+
+			"expected %d", len(opening.Seeds), instances)
+	}
+	if verbose {
+		fmt.Printf(" - Checking %d instance(s)...\n", instances/2)
+	}
+	for i := range check {
+		if !check[i] {
+			continue
+		}
+		inst, err := shared.garbleSeed(cfg, opening.Seeds[i])
+		if err != nil {
+			return nil, errors.Wrapf(ErrCheatingDetected,
+				"instance %d: invalid seed: %v", i, err)
+		}
+		if subtle.ConstantTimeCompare(inst.digest,
+			commitment.Digests[i]) != 1 {
+			return nil, errors.Wrapf(ErrCheatingDetected,
+				"instance %d: garbled circuit differs from commitment", i)
+		}
+		wires := inst.garbled.Wires[n0 : n0+n1]
+		for bit, wire := range wires {
+			l := LabelForBit(wire, flags[bit])
+			if !l.Equal(received[i*n1+bit]) {
+				return nil, errors.Wrapf(ErrCheatingDetected,
+					"instance %d: invalid OT label for input %d", i, bit)
+			}
+		}
+		var zeros []ot.Label
+		for _, wire := range inst.garblerInputs(shared) {
+			zeros = append(zeros, wire.L0)
+		}
+		if ccHash(m, zeros) != decoding[i] {
+			return nil, errors.Wrapf(ErrCheatingDetected,
+				"instance %d: invalid hash decoding", i)
+		}
+	}
+
+	// C7. 接收并评估其余实例. 混淆方的输入必须在所有实例中一致.
+	if verbose {
+		fmt.Printf(" - Evaluating %d instance(s)...\n",
+			instances-instances/2)
+	}
+	var inputHash uint64
+	var inputHashSet bool
+	var results []*big.Int
+	for i := range check {
+		if check[i] {
+			continue
+		}
+		labels := opening.Inputs[i]
+		if len(labels) != n0+ccPadBits {
+			return nil, fmt.Errorf("peer sent %d input labels, expected %d",
+				len(labels), n0+ccPadBits)
+		}
+		c := ccInputCommitment(opening.Nonces[i], labels)
+		if subtle.ConstantTimeCompare(c, commitment.Inputs[i]) != 1 {
+			return nil, errors.Wrapf(ErrCheatingDetected,
+				"instance %d: input labels differ from commitment", i)
+		}
+		h := ccHash(m, labels) ^ decoding[i]
+		if inputHashSet && h != inputHash {
+			return nil, errors.Wrapf(ErrCheatingDetected,
+				"instance %d: inconsistent garbler inputs", i)
+		}
+		inputHash = h
+		inputHashSet = true
+
+		wires := make([]ot.Label, shared.NumWires)
+		copy(wires, labels[:n0])
+		copy(wires[n0:], received[i*n1:(i+1)*n1])
+
+		result, err := evalCutAndChoose(cfg, conn, shared, opening.Keys[i],
+			commitment.Digests[i], wires)
+		if errors.Is(err, ErrCheatingDetected) {
+			return nil, errors.Wrapf(err, "instance %d", i)
+		} else if err != nil && !errors.Is(err, errInvalidInstance) {
+			return nil, err
+		}
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+
+	// 多数结果. 无效实例不参与投票.
+	var result *big.Int
+	var votes int
+	for _, r := range results {
+		var count int
+		for _, o := range results {
+			if r.Cmp(o) == 0 {
+				count++
+			}
+		}
+		if count > votes {
+			result = r
+			votes = count
+		}
+	}
+	if votes*2 <= instances-instances/2 {
+		return nil, errors.Wrapf(ErrCheatingDetected,
+			"no majority output: %d of %d instances agree", votes,
+			instances-instances/2)
+	}
+
+	// C8. 发送双方可见的结果
+	revealed := big.NewInt(0)
+	for i, reveal := range circ.Outputs.Mask(PartyGarbler) {
+		if reveal {
+			revealed.SetBit(revealed, i, result.Bit(i))
+		}
+	}
+	if err := conn.DirectSend(revealed, "result"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when sending result.")
+		return nil, err
+	}
+
+	// C9. 确认通信记录
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when confirming transcript.")
+		return nil, err
+	}
+	values := circ.Outputs.Split(result)
+	return circ.Outputs.Hide(values, PartyEvaluator), nil
+}
+
+// errInvalidInstance is returned when an evaluation instance produces
+// invalid output labels.
+var errInvalidInstance = errors.New("invalid instance")
+
+// evalCutAndChoose receives and evaluates an evaluation instance of
+// the cut-and-choose protocol. The function verifies the received
+// garbled circuit against the garbler's commitment and returns the
+// decoded outputs. If the instance is garbled correctly but its
+// evaluation fails, the function returns errInvalidInstance.
+func evalCutAndChoose(cfg *utils.Config, conn *ot.Conn, circ *Circuit,
+	key [32]byte, digest []byte, wires []ot.Label) (*big.Int, error) {
+
+	stream, err := circ.NewEvalStream(key[:], wires, cfg.Garbling)
+	if err != nil {
+		return nil, err
+	}
+	stream.SetWorkers(cfg.Workers)
+
+	// The garbled gates are received in full also if an invalid gate
+	// stops the evaluation.
+	d := newCCDigest(key)
+	var evalErr error
+	for received := 0; received < circ.NumGates; {
+		var chunk gateChunk
+		if err := conn.DirectRecv(&chunk, "garbled gates"); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::EvaluatorCutAndChoose(...), when receiving garbled gates.")
+			return nil, err
+		}
+		gates, err := chunk.decode(stream.scheme, circ.Gates[received:])
+		if err != nil {
+			return nil, err
+		}
+		received += len(gates)
+		d.gates(gates)
+		if evalErr == nil {
+			evalErr = stream.Eval(gates)
+		}
+	}
+	var decoding []OutputDecoding
+	if err := conn.DirectRecv(&decoding, "output decoding"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorCutAndChoose(...), when receiving output decoding.")
+		return nil, err
+	}
+	d.decoding(decoding)
+	if subtle.ConstantTimeCompare(d.sum(), digest) != 1 {
+		return nil, errors.Wrap(ErrCheatingDetected,
+			"garbled circuit differs from commitment")
+	}
+	if evalErr != nil {
+		return nil, errInvalidInstance
+	}
+	size := circ.Outputs.Size()
+	result, err := circ.DecodeOutputs(wires[circ.NumWires-size:], decoding)
+	if err != nil {
+		return nil, errInvalidInstance
+	}
+	return result, nil
+}
@@ -0,0 +1,112 @@
+//
+// decoding.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// ErrInvalidOutputLabel is returned when an output label does not
+// match the output decoding information.
+var ErrInvalidOutputLabel = errors.New("invalid output label")
+
+// OutputDecoding holds the hashed 0 and 1 labels of an output wire.
+type OutputDecoding struct {
+	H0 [sha256.Size]byte
+	H1 [sha256.Size]byte
+}
+
+func hashOutputLabel(idx int, label ot.Label) [sha256.Size]byte {
+	var buf [8 + 16]byte
+	var data ot.LabelData
+
+	binary.BigEndian.PutUint64(buf[0:8], uint64(idx))
+	label.GetData(&data)
+	copy(buf[8:], data[:])
+
+	return sha256.Sum256(buf[:])
+}
+
+// OutputDecoding returns the output decoding information for the
+// circuit outputs. The decoding information is left empty for the
+// outputs that are not revealed to the evaluator.
+func (g *Garbled) OutputDecoding(c *Circuit) []OutputDecoding {
+	size := c.Outputs.Size()
+	mask := c.Outputs.Mask(PartyEvaluator)
+	result := make([]OutputDecoding, size)
+
+	for i := 0; i < size; i++ {
+		if !mask[i] {
+			continue
+		}
+		wire := g.Wires[c.NumWires-size+i]
+		result[i] = OutputDecoding{
+			H0: hashOutputLabel(i, wire.L0),
+			H1: hashOutputLabel(i, wire.L1),
+		}
+	}
+	return result
+}
+
+// DecodeOutputs decodes the output labels with the output decoding
+// information. The outputs that are not revealed to the evaluator are
+// left as zero. The function returns ErrInvalidOutputLabel if any of
+// the labels does not match its decoding information.
+func (c *Circuit) DecodeOutputs(labels []ot.Label,
+	decoding []OutputDecoding) (*big.Int, error) {
+
+	size := c.Outputs.Size()
+	if len(labels) != size || len(decoding) != size {
+		return nil, errors.Newf("invalid output decoding: "+
+			"got %d labels and %d decodings, expected %d",
+			len(labels), len(decoding), size)
+	}
+
+	mask := c.Outputs.Mask(PartyEvaluator)
+	result := big.NewInt(0)
+	for i := 0; i < size; i++ {
+		if !mask[i] {
+			continue
+		}
+		h := hashOutputLabel(i, labels[i])
+		switch h {
+		case decoding[i].H0:
+		case decoding[i].H1:
+			result.SetBit(result, i, 1)
+		default:
+			return nil, errors.Wrapf(ErrInvalidOutputLabel, "output %d", i)
+		}
+	}
+	return result, nil
+}
+
+// checkOutputMode verifies that the output mode can reveal the
+// circuit outputs to their parties.
+func (c *Circuit) checkOutputMode(mode utils.OutputMode) error {
+	if mode != utils.OutputGarbler {
+		return nil
+	}
+	for _, out := range c.Outputs {
+		if out.Party == PartyEvaluator {
+			return errors.Newf("output mode %v can't reveal output %v "+
+				"only to the evaluator", mode, out)
+		}
+		if out.Share == ShareAdditive {
+			return errors.Newf("output mode %v can't share output %v "+
+				"additively", mode, out)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+//
+// decoding_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/markkurossi/mpc/ot"
+)
+
+func TestDecodeOutputs(t *testing.T) {
+	circ, err := ParseBristol(bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+	var key [32]byte
+	garbled, err := circ.Garble(rand.Reader, key[:])
+	if err != nil {
+		t.Fatalf("Garble failed: %s", err)
+	}
+	decoding := garbled.OutputDecoding(circ)
+	wire := garbled.Wires[circ.NumWires-1]
+
+	for bit, label := range []ot.Label{wire.L0, wire.L1} {
+		result, err := circ.DecodeOutputs([]ot.Label{label}, decoding)
+		if err != nil {
+			t.Fatalf("DecodeOutputs failed: %s", err)
+		}
+		if result.Int64() != int64(bit) {
+			t.Errorf("DecodeOutputs: got %v, expected %v", result, bit)
+		}
+	}
+
+	invalid := wire.L0
+	invalid.Xor(wire.L1)
+	_, err = circ.DecodeOutputs([]ot.Label{invalid}, decoding)
+	if !errors.Is(err, ErrInvalidOutputLabel) {
+		t.Errorf("expected invalid output label, got %v", err)
+	}
+}
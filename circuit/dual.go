@@ -0,0 +1,378 @@
+//
+// dual.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/ot/drbg"
+)
+
+// ErrOutputMismatch is returned when the dual-execution parties
+// computed different outputs.
+var ErrOutputMismatch = errors.New("output mismatch")
+
+// Dual-execution channels. The garbler of the connection garbles the
+// circuit in the channel dualA and the evaluator of the connection
+// garbles the circuit with swapped inputs in the channel dualB.
+const (
+	phaseDual = "dual"
+	dualA     = "dual-a"
+	dualB     = "dual-b"
+)
+
+// DualExecution runs the dual-execution protocol of Huang, Katz, and
+// Evans: "Quid-Pro-Quo-tocols: Strengthening Semi-Honest Protocols with
+// Dual Execution", IEEE S&P 2012. Both parties garble the circuit and
+// evaluate the peer's garbled circuit in parallel over the connection.
+// Before the outputs are released, the parties run an equality test
+// over the output labels of both executions. The protocol is secure
+// against a malicious peer that can learn at most one bit of our
+// inputs: whether its misbehavior changed the outputs. The protocol
+// costs roughly twice the semi-honest protocol.
+//
+// The party specifies our role in the connection and our input is the
+// circuit input of the role. The circuit must have two inputs and its
+// outputs must be revealed to both parties in the OutputDecode output
+// mode. The function returns ErrOutputMismatch if the executions
+// computed different outputs.
+func DualExecution(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	party Party,
+	inputs *big.Int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	result, err := dualExecution(cfg, conn, oti, circ, party, inputs, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return result, err
+}
+
+func dualExecution(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	party Party,
+	inputs *big.Int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	if cfg.OutputMode != utils.OutputDecode {
+		return nil, fmt.Errorf("dual execution needs output mode %v, got %v",
+			utils.OutputDecode, cfg.OutputMode)
+	}
+	if cfg.Checkpoints != nil {
+		return nil, fmt.Errorf("dual execution sessions are not resumable")
+	}
+	if len(circ.Inputs) != 2 {
+		return nil, fmt.Errorf("dual execution needs 2 parties, got %d",
+			len(circ.Inputs))
+	}
+	for _, out := range circ.Outputs {
+		if !out.RevealedTo(PartyGarbler) || !out.RevealedTo(PartyEvaluator) {
+			return nil, fmt.Errorf("dual execution can't reveal output %v "+
+				"only to one party", out)
+		}
+	}
+	var ours, theirs string
+	switch party {
+	case PartyGarbler:
+		ours, theirs = dualA, dualB
+	case PartyEvaluator:
+		ours, theirs = dualB, dualA
+	default:
+		return nil, fmt.Errorf("invalid dual execution party %v", party)
+	}
+
+	// The garbling and OT of our garbled circuit, and the OT of the
+	// peer's garbled circuit run concurrently with their own DRBGs.
+	grand, err := sessionRandom(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+	erand, err := drbg.NewFromReader(grand.Algorithm(), grand)
+	if err != nil {
+		return nil, err
+	}
+
+	// D0. 握手: 确认协议版本, 电路和角色一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party: party,
+		Phase: phaseDual,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when exchanging handshake.")
+		return nil, err
+	}
+
+	// Our circuit has our inputs as the garbler's inputs.
+	gcirc := circ
+	ecirc := circ.swapInputs()
+	if party == PartyEvaluator {
+		gcirc, ecirc = ecirc, gcirc
+	}
+	gconn, err := conn.Channel(ours)
+	if err != nil {
+		return nil, err
+	}
+	econn, err := conn.Channel(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	// D1. 并行执行: 混淆我方电路, 评估对方电路. 任一方向失败时立即
+	// 中止协议, 使另一方向的对方不再等待.
+	fail := func(err error) {
+		if err != nil && shouldAbort(cfg, err) {
+			conn.Abort(abortCode(err), err)
+		}
+	}
+	gch := make(chan error, 1)
+	var garbled *garblerInstance
+	go func() {
+		var err error
+		garbled, err = dualGarble(cfg, gconn, oti, grand, gcirc, inputs,
+			verbose)
+		fail(err)
+		gch <- err
+	}()
+	values, labels, err := dualEvaluate(cfg, econn, oti.WithRandom(erand),
+		ecirc, inputs, verbose)
+	fail(err)
+	if gerr := <-gch; err == nil {
+		err = gerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// D2. 相等性测试: 双方计算相同的输出时, 两个电路的输出 labels 一致.
+	// 我方电路使用对方电路的输出值选择 labels.
+	h := sha256.New()
+	var data ot.LabelData
+	write := func(l ot.Label) {
+		l.GetData(&data)
+		h.Write(data[:])
+	}
+	if party == PartyGarbler {
+		for i, wire := range garbled.outputs {
+			write(LabelForBit(wire, values.Bit(i) == 1))
+		}
+		for _, l := range labels {
+			write(l)
+		}
+	} else {
+		for _, l := range labels {
+			write(l)
+		}
+		for i, wire := range garbled.outputs {
+			write(LabelForBit(wire, values.Bit(i) == 1))
+		}
+	}
+	sum := h.Sum(nil)
+
+	// The parties commit to their sums before opening them so that
+	// the peer can't echo our sum back. The commitments are bound to
+	// the committing party so that the peer can't echo our commitment
+	// either.
+	nonce := make([]byte, 32)
+	if _, err := io.ReadFull(grand, nonce); err != nil {
+		return nil, err
+	}
+	opening := dualOpening{
+		Nonce: nonce,
+		Sum:   sum,
+	}
+	var peer dualOpening
+	var peerCommitment []byte
+	if party == PartyGarbler {
+		err = conn.DirectSend(opening.commitment(party), "equality commit")
+		if err == nil {
+			err = conn.DirectRecv(&peerCommitment, "equality commit")
+		}
+		if err == nil {
+			err = conn.DirectSend(opening, "equality open")
+		}
+		if err == nil {
+			err = conn.DirectRecv(&peer, "equality open")
+		}
+	} else {
+		err = conn.DirectRecv(&peerCommitment, "equality commit")
+		if err == nil {
+			err = conn.DirectSend(opening.commitment(party),
+				"equality commit")
+		}
+		if err == nil {
+			err = conn.DirectRecv(&peer, "equality open")
+		}
+		if err == nil {
+			err = conn.DirectSend(opening, "equality open")
+		}
+	}
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when testing output equality.")
+		return nil, err
+	}
+	peerParty := PartyEvaluator
+	if party == PartyEvaluator {
+		peerParty = PartyGarbler
+	}
+	if subtle.ConstantTimeCompare(peer.commitment(peerParty),
+		peerCommitment) != 1 {
+		return nil, ErrOutputMismatch
+	}
+	if subtle.ConstantTimeCompare(sum, peer.Sum) != 1 {
+		return nil, ErrOutputMismatch
+	}
+
+	// D3. 确认通信记录
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when confirming transcript.")
+		return nil, err
+	}
+	return circ.Outputs.Split(values), nil
+}
+
+// dualOpening opens a party's commitment to its output label sum in
+// the equality test.
+type dualOpening struct {
+	Nonce []byte
+	Sum   []byte
+}
+
+// commitment returns the commitment of the party to the sum.
+func (o dualOpening) commitment(party Party) []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "dual-execution equality %v\n", party)
+	h.Write(o.Nonce)
+	h.Write(o.Sum)
+	return h.Sum(nil)
+}
+
+// dualGarble garbles our circuit and sends it to the peer. The
+// function returns the garbled instance whose output wires the
+// equality test uses.
+func dualGarble(cfg *utils.Config, conn *ot.Conn, oti *ot.CO,
+	rand io.Reader, circ *Circuit, inputs *big.Int, verbose bool) (
+	*garblerInstance, error) {
+
+	oti = oti.WithRandom(rand)
+	circ, ginsts, einsts, err := newInstances(cfg, rand, circ, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// G1. 发送临时密钥
+	keys := [][32]byte{einsts[0].Key}
+	if err := conn.DirectSend(keys, "ephemeral key"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when sending ephemeral key.")
+		return nil, err
+	}
+	err = garblerSend(cfg, conn, oti, nil, circ, ginsts,
+		[]*big.Int{inputs}, verbose)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when confirming transcript.")
+		return nil, err
+	}
+	return ginsts[0], nil
+}
+
+// dualEvaluate evaluates the peer's garbled circuit. The function
+// returns the decoded output values and the output labels.
+func dualEvaluate(cfg *utils.Config, conn *ot.Conn, oti *ot.CO,
+	circ *Circuit, inputs *big.Int, verbose bool) (
+	*big.Int, []ot.Label, error) {
+
+	// E1. 接收临时密钥.
+	var keys [][32]byte
+	if err := conn.DirectRecv(&keys, "ephemeral key"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when receiving ephemeral key.")
+		return nil, nil, err
+	}
+	if len(keys) != 1 {
+		return nil, nil, fmt.Errorf("peer sent %d keys, expected 1",
+			len(keys))
+	}
+	insts := []*evaluatorInstance{{
+		Key: keys[0],
+	}}
+	wires, err := evaluatorReceive(cfg, conn, oti, nil, circ, insts,
+		[]*big.Int{inputs}, verbose)
+	if err != nil {
+		return nil, nil, err
+	}
+	size := circ.Outputs.Size()
+	labels := wires[0][circ.NumWires-size:]
+
+	values, err := circ.DecodeOutputs(labels, insts[0].Decoding)
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when decoding outputs.")
+		return nil, nil, err
+	}
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::DualExecution(...), when confirming transcript.")
+		return nil, nil, err
+	}
+	return values, labels, nil
+}
+
+// swapInputs returns a copy of the two-party circuit with its inputs
+// swapped. The input wires of the second input become the first wires
+// of the circuit and the other wires keep their IDs.
+func (c *Circuit) swapInputs() *Circuit {
+	n0 := Wire(c.Inputs[0].Type.Bits)
+	n1 := Wire(c.Inputs[1].Type.Bits)
+	remap := func(w Wire) Wire {
+		switch {
+		case w < n0:
+			return w + n1
+		case w < n0+n1:
+			return w - n0
+		default:
+			return w
+		}
+	}
+	result := *c
+	result.Inputs = IO{c.Inputs[1], c.Inputs[0]}
+	result.Gates = make([]Gate, len(c.Gates))
+	for i, g := range c.Gates {
+		g.Input0 = remap(g.Input0)
+		if g.Op != INV {
+			g.Input1 = remap(g.Input1)
+		}
+		result.Gates[i] = g
+	}
+	return &result
+}
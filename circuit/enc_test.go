@@ -86,3 +86,26 @@ func BenchmarkEncHalf(b *testing.B) {
 		encryptHalf(cipher, xl, uint32(i), &data)
 	}
 }
+
+func BenchmarkMMOHash(b *testing.B) {
+	var key [32]byte
+
+	h, err := newMMOHash(key[:])
+	if err != nil {
+		b.Fatalf("Failed to create hash: %s", err)
+	}
+	var x [4]ot.Label
+	for i := range x {
+		x[i], err = ot.NewLabel(rand.Reader)
+		if err != nil {
+			b.Fatalf("Failed to create label: %s", err)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t := uint32(i)
+		h.Hash(x[:], []uint32{t, t, t + 1, t + 1})
+	}
+}
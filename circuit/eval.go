@@ -7,109 +7,118 @@
 package circuit
 
 import (
-	"crypto/aes"
 	"fmt"
 
+	"github.com/markkurossi/mpc/compiler/utils"
 	"github.com/markkurossi/mpc/ot"
 )
 
 // Eval evaluates the circuit.
 func (c *Circuit) Eval(key []byte, wires []ot.Label,
 	garbled [][]ot.Label) error {
+	return c.eval(key, wires, garbled, utils.HalfGates, 1)
+}
+
+// EvalParallel evaluates the circuit like Eval but it evaluates the
+// gates of each circuit level with the argument number of workers.
+func (c *Circuit) EvalParallel(key []byte, wires []ot.Label,
+	garbled [][]ot.Label, workers int) error {
+	return c.eval(key, wires, garbled, utils.HalfGates, workers)
+}
+
+func (c *Circuit) eval(key []byte, wires []ot.Label, garbled [][]ot.Label,
+	scheme utils.Garbling, workers int) error {
 
-	alg, err := aes.NewCipher(key)
+	stream, err := c.NewEvalStream(key, wires, scheme)
 	if err != nil {
 		return err
 	}
+	stream.SetWorkers(workers)
+	if err := stream.Eval(garbled); err != nil {
+		return err
+	}
+	if !stream.Done() {
+		return fmt.Errorf("corrupted circuit: %d gates, expected %d",
+			len(garbled), c.NumGates)
+	}
+	return nil
+}
 
-	var data ot.LabelData
-	var id uint32
+// EvalStream evaluates the circuit gate chunk by gate chunk as the
+// garbled gates arrive.
+type EvalStream struct {
+	c       *Circuit
+	scheme  GarblingScheme
+	wires   []ot.Label
+	id      uint32
+	next    int
+	data    ot.LabelData
+	workers int
+	sched   *schedule
+}
 
-	for i := 0; i < len(c.Gates); i++ {
-		gate := &c.Gates[i]
+// NewEvalStream creates a new evaluation stream for the circuit with
+// the garbling scheme. The wires must have the input wire labels set.
+func (c *Circuit) NewEvalStream(key []byte, wires []ot.Label,
+	scheme utils.Garbling) (*EvalStream, error) {
 
-		var a, b, c ot.Label
+	gs, err := NewGarblingScheme(scheme, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EvalStream{
+		c:      c,
+		scheme: gs,
+		wires:  wires,
+	}, nil
+}
 
-		switch gate.Op {
-		case XOR, XNOR, AND, OR:
-			a = wires[gate.Input0]
-			b = wires[gate.Input1]
+// Done tests if all gates are evaluated.
+func (s *EvalStream) Done() bool {
+	return s.next >= len(s.c.Gates)
+}
 
-		case INV:
-			a = wires[gate.Input0]
+// Eval evaluates the next len(garbled) gates.
+func (s *EvalStream) Eval(garbled [][]ot.Label) error {
+	if len(garbled) > len(s.c.Gates)-s.next {
+		return fmt.Errorf("corrupted circuit: %d extra gates",
+			len(garbled)-(len(s.c.Gates)-s.next))
+	}
+	from := s.next
+	s.next += len(garbled)
 
-		default:
-			return fmt.Errorf("invalid operation %s", gate.Op)
+	if s.workers > 1 {
+		return s.parallel(from, garbled)
+	}
+	for i := 0; i < len(garbled); i++ {
+		err := s.eval(&s.c.Gates[from+i], garbled[i], &s.id, &s.data)
+		if err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		var output ot.Label
-
-		switch gate.Op {
-		case XOR, XNOR:
-			a.Xor(b)
-			output = a
-
-		case AND:
-			row := garbled[i]
-			if len(row) != 2 {
-				return fmt.Errorf("corrupted ciruit: AND row length: %d",
-					len(row))
-			}
-			sa := a.S()
-			sb := b.S()
-
-			j0 := id
-			j1 := id + 1
-			id += 2
-
-			tg := row[0]
-			te := row[1]
-
-			wg := encryptHalf(alg, a, j0, &data)
-			if sa {
-				wg.Xor(tg)
-			}
-			we := encryptHalf(alg, b, j1, &data)
-			if sb {
-				we.Xor(te)
-				we.Xor(a)
-			}
-			output = wg
-			output.Xor(we)
-
-		case OR:
-			row := garbled[i]
-			index := idx(a, b)
-			if index > 0 {
-				// First row is zero and not transmitted.
-				index--
-				if index >= len(row) {
-					return fmt.Errorf("corrupted circuit: index %d >= row %d",
-						index, len(row))
-				}
-				c = row[index]
-			}
-
-			output = decrypt(alg, a, b, id, c, &data)
-			id++
-
-		case INV:
-			row := garbled[i]
-			index := idxUnary(a)
-			if index > 0 {
-				// First row is zero and not transmitted.
-				index--
-				if index >= len(row) {
-					return fmt.Errorf("corrupted circuit: index %d >= row %d",
-						index, len(row))
-				}
-				c = row[index]
-			}
-			output = decrypt(alg, a, ot.Label{}, id, c, &data)
-			id++
-		}
-		wires[gate.Output] = output
+// evalChunk decodes the garbled tables of the chunk and evaluates the
+// chunk's gates.
+func (s *EvalStream) evalChunk(chunk *gateChunk) error {
+	tables, err := chunk.decode(s.scheme, s.c.Gates[s.next:])
+	if err != nil {
+		return err
+	}
+	return s.Eval(tables)
+}
+
+// eval evaluates the gate with the stream's garbling scheme and sets
+// its output wire label.
+func (s *EvalStream) eval(g *Gate, row []ot.Label, idp *uint32,
+	data *ot.LabelData) error {
+
+	err := s.scheme.Eval(g, s.wires, row, *idp, data)
+	if err != nil {
+		return err
 	}
+	*idp += s.scheme.Tweaks(g.Op)
 
 	return nil
 }
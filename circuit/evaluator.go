@@ -13,11 +13,22 @@ import (
 	"math/big"
 
 	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
 	"github.com/markkurossi/mpc/ot"
 )
 
-// Evaluator runs the evaluator on the P2P network.
+// Evaluator runs the evaluator on the P2P network. If the evaluator
+// fails, it aborts the protocol so that the garbler fails immediately.
+// The evaluator draws all its randomness, including the OT
+// randomness, from a session DRBG seeded from the configuration. If
+// the configuration has a checkpoint store, the evaluator resumes the
+// session from its checkpoint.
+//
+// If the configuration selects the AuthGarbling protocol, the evaluator
+// runs the maliciously secure authenticated garbling protocol. The
+// protocol supports one circuit instance without checkpoints.
 func Evaluator(
+	cfg *utils.Config,
 	conn *ot.Conn,
 	oti *ot.CO,
 	circ *Circuit,
@@ -26,39 +37,254 @@ func Evaluator(
 ) (
 	[]*big.Int, error,
 ) {
+	results, err := EvaluatorBatch(cfg, conn, oti, circ, []*big.Int{inputs},
+		verbose)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// EvaluatorBatch runs the evaluator for a batch of circuit instances
+// in one session. The function returns the results in the order of
+// the input sets. The garbler must run GarblerBatch with the same
+// number of input sets.
+func EvaluatorBatch(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs []*big.Int,
+	verbose bool,
+) (
+	[][]*big.Int, error,
+) {
+	results, err := evaluator(cfg, conn, oti, circ, inputs, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return results, err
+}
+
+func evaluator(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs []*big.Int,
+	verbose bool,
+) (
+	[][]*big.Int, error,
+) {
+	n := len(inputs)
+	if n == 0 {
+		return nil, fmt.Errorf("no input sets")
+	}
+	if err := circ.checkOutputMode(cfg.OutputMode); err != nil {
+		return nil, err
+	}
+	if cfg.Protocol == utils.AuthGarbling {
+		if len(inputs) != 1 {
+			return nil, fmt.Errorf("authenticated garbling batches not supported")
+		}
+		result, err := authGarbling(cfg, conn, oti, circ, PartyEvaluator,
+			inputs[0], verbose)
+		if err != nil {
+			return nil, err
+		}
+		return [][]*big.Int{result}, nil
+	}
+
+	sess, rand, err := newSession(cfg, conn, circ, PartyEvaluator)
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Evaluator(...), when loading checkpoint.")
+		return nil, err
+	}
+	oti = oti.WithRandom(rand)
+
+	// E0. 握手: 确认协议版本, 电路和实例数一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyEvaluator,
+		Instances: n,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Evaluator(...), when exchanging handshake.")
+		return nil, err
+	}
+	if err := sess.step(0); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Evaluator(...), when saving checkpoint.")
+		return nil, err
+	}
+
+	circ, err = circ.expandShares()
+	if err != nil {
+		return nil, err
+	}
+
 	// E1. 接收临时密钥.
 	if verbose {
 		fmt.Printf(" - Waiting for circuit info...\n")
 	}
-	var key [32]byte
-	if err := conn.DirectRecv(&key, "ephemeral key"); err != nil {
+	var keys [][32]byte
+	if err := conn.DirectRecv(&keys, "ephemeral key"); err != nil {
 		err = errors.Wrap(err,
 			"in mpc_hd::Evaluator(...), when receiving ephemeral key.")
 		return nil, err
 	}
+	if len(keys) != n {
+		return nil, fmt.Errorf("peer sent %d keys, expected %d", len(keys), n)
+	}
+	insts := make([]*evaluatorInstance, n)
+	for i := range insts {
+		insts[i] = &evaluatorInstance{
+			Key: keys[i],
+		}
+	}
 
-	// E2. 接收 gates
-	if verbose {
-		fmt.Printf(" - Receiving garbled circuit...\n")
+	return evaluatorOnline(cfg, conn, oti, sess, circ, insts, inputs,
+		verbose)
+}
+
+// evaluatorOnline runs the online phase of the evaluator for the
+// garbled instances. The circuit must have its output shares expanded.
+// If the instances do not have their garbled gates, the function
+// receives and evaluates the gates chunk by chunk after the OT.
+func evaluatorOnline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	sess *session,
+	circ *Circuit,
+	insts []*evaluatorInstance,
+	inputs []*big.Int,
+	verbose bool,
+) (
+	[][]*big.Int, error,
+) {
+	n := len(insts)
+
+	wires, err := evaluatorReceive(cfg, conn, oti, sess, circ, insts, inputs,
+		verbose)
+	if err != nil {
+		return nil, err
 	}
-	garbled := make([][]ot.Label, circ.NumGates)
-	if err := conn.DirectRecv(&garbled, "garbled gates"); err != nil {
+
+	results := make([]*big.Int, n)
+	shares := make([][]*big.Int, n)
+	labels := make([][]ot.Label, n)
+	for idx, inst := range insts {
+		for i := 0; i < circ.Outputs.Size(); i++ {
+			r := wires[idx][Wire(circ.NumWires-circ.Outputs.Size()+i)]
+			labels[idx] = append(labels[idx], r)
+		}
+
+		// 本地解码结果. 标签无效时不发送结果 labels.
+		results[idx] = new(big.Int)
+		if cfg.OutputMode == utils.OutputDecode {
+			var err error
+			results[idx], err = circ.DecodeOutputs(labels[idx], inst.Decoding)
+			if err != nil {
+				err = errors.Wrap(err,
+					"in mpc_hd::Evaluator(...), when decoding outputs.")
+				return nil, err
+			}
+		}
+		shares[idx] = circ.xorShares(labels[idx])
+
+		// 不发送仅评估方可见的输出.
+		for i, reveal := range circ.Outputs.Mask(PartyGarbler) {
+			if !reveal {
+				labels[idx][i] = ot.Label{}
+			}
+		}
+	}
+
+	// E7. 发送结果 labels.
+	if err := conn.DirectSend(&labels, "result labels"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Evaluator(...), when sending ot labels.")
+		return nil, err
+	}
+	if cfg.OutputMode == utils.OutputGarbler {
+		if err := conn.DirectRecv(&results, "result"); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::Evaluator(...), when receiving result.")
+			return nil, err
+		}
+		if len(results) != n {
+			return nil, fmt.Errorf("peer sent %d results, expected %d",
+				len(results), n)
+		}
+	}
+
+	// E8. 确认通信记录. 不一致时不返回结果.
+	if err := conn.ConfirmTranscript(); err != nil {
 		err = errors.Wrap(err,
-			"in mpc_hd::Evaluator(...), when receiving garbled gates.")
+			"in mpc_hd::Evaluator(...), when confirming transcript.")
 		return nil, err
 	}
+	if err := sess.done(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Evaluator(...), when removing checkpoint.")
+		return nil, err
+	}
+
+	outputs := make([][]*big.Int, n)
+	for idx, result := range results {
+		values := circ.Outputs.Split(result)
+		values = circ.Outputs.Hide(values, PartyEvaluator)
+		outputs[idx] = setShares(values, shares[idx])
+	}
+	return outputs, nil
+}
 
-	// E3. 接收 inputs
-	var wires []ot.Label
+// evaluatorReceive receives and evaluates the garbled instances. The
+// function receives the garbler's input labels, our input labels with
+// OT, and the garbled gates and output decoding of the streamed
+// instances. It returns the evaluated wire labels of the instances.
+func evaluatorReceive(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	sess *session,
+	circ *Circuit,
+	insts []*evaluatorInstance,
+	inputs []*big.Int,
+	verbose bool,
+) (
+	[][]ot.Label, error,
+) {
+	n := len(insts)
+
+	// E2. 接收 inputs
+	var wires [][]ot.Label
 	if err := conn.DirectRecv(&wires, "inputs"); err != nil {
 		err = errors.Wrap(err,
 			"in mpc_hd::Evaluator(...), when receiving inputs.")
 		return nil, err
 	}
-	padlen := circ.NumWires - len(wires)
-	wires = append(wires, make([]ot.Label, padlen)...)
+	if len(wires) != n {
+		return nil, fmt.Errorf("peer sent inputs for %d instances, "+
+			"expected %d", len(wires), n)
+	}
+	for i := range wires {
+		if len(wires[i]) != int(circ.Inputs[0].Type.Bits) {
+			return nil, fmt.Errorf("peer sent %d input labels, expected %d",
+				len(wires[i]), circ.Inputs[0].Type.Bits)
+		}
+		padlen := circ.NumWires - len(wires[i])
+		wires[i] = append(wires[i], make([]ot.Label, padlen)...)
+	}
+	if err := sess.step(2); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Evaluator(...), when saving checkpoint.")
+		return nil, err
+	}
 
-	// E4. 发送 offset 和 count
+	// E3. 发送 offset 和 count
 	if verbose {
 		fmt.Printf(" - Querying our inputs...\n")
 	}
@@ -75,48 +301,100 @@ func Evaluator(
 			"in mpc_hd::Evaluator(...), when sending ot query.")
 		return nil, err
 	}
-	flags := make([]bool, int(circ.Inputs[1].Type.Bits))
-	for i := 0; i < int(circ.Inputs[1].Type.Bits); i++ {
-		if inputs.Bit(i) == 1 {
-			flags[i] = true
+	var flags []bool
+	for _, input := range inputs {
+		for i := 0; i < query.Count; i++ {
+			flags = append(flags, input.Bit(i) == 1)
 		}
 	}
-	start := int(circ.Inputs[0].Type.Bits)
-	end := start + int(circ.Inputs[1].Type.Bits)
+	received := make([]ot.Label, len(flags))
 
-	// E5. 执行 ot 接收. 位于 ot/co.go: func Receive
-	if err := oti.Receive(flags, wires[start:end], conn); err != nil {
+	// E4. 执行 ot 接收. 位于 ot/co.go: func Receive. 所有实例共用一次 OT.
+	if err := oti.Receive(flags, received, conn); err != nil {
+		return nil, err
+	}
+	start := query.Offset
+	end := start + query.Count
+	for i := range wires {
+		copy(wires[i][start:end], received[i*query.Count:])
+	}
+	if err := sess.step(4); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Evaluator(...), when saving checkpoint.")
 		return nil, err
 	}
 
-	// Evaluate gates.
+	// E5. 评估 gates. 流式接收时逐块接收并评估.
 	if verbose {
 		fmt.Printf(" - Evaluating circuit...\n")
 	}
-	if err := circ.Eval(key[:], wires, garbled); err != nil {
-		err = errors.Wrap(err,
-			"in mpc_hd::Evaluator(...), when evaluating gates.")
-		return nil, err
+	var streamed bool
+	for idx, inst := range insts {
+		if inst.Gates == nil {
+			streamed = true
+			err := evalStream(cfg, conn, circ, inst.Key[:], wires[idx])
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err := circ.eval(inst.Key[:], wires[idx], inst.Gates, cfg.Garbling,
+			cfg.Workers)
+		if err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::Evaluator(...), when evaluating gates.")
+			conn.Abort(ot.AbortEvaluation, err)
+			return nil, err
+		}
 	}
 
-	// E6. 发送结果 labels
-	var labels []ot.Label
-	for i := 0; i < circ.Outputs.Size(); i++ {
-		r := wires[Wire(circ.NumWires-circ.Outputs.Size()+i)]
-		labels = append(labels, r)
+	// E6. 接收输出解码信息
+	if streamed && cfg.OutputMode == utils.OutputDecode {
+		var decoding [][]OutputDecoding
+		if err := conn.DirectRecv(&decoding, "output decoding"); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::Evaluator(...), when receiving output decoding.")
+			return nil, err
+		}
+		if len(decoding) != n {
+			return nil, fmt.Errorf("peer sent %d output decodings, "+
+				"expected %d", len(decoding), n)
+		}
+		for idx, inst := range insts {
+			inst.Decoding = decoding[idx]
+		}
 	}
-	if err := conn.DirectSend(&labels, "result labels"); err != nil {
+	if err := sess.step(6); err != nil {
 		err = errors.Wrap(err,
-			"in mpc_hd::Evaluator(...), when sending ot labels.")
+			"in mpc_hd::Evaluator(...), when saving checkpoint.")
 		return nil, err
 	}
+	return wires, nil
+}
 
-	// E7. 接收结果.
-	var result big.Int
-	if err := conn.DirectRecv(&result, "result"); err != nil {
-		err = errors.Wrap(err,
-			"in mpc_hd::Evaluator(...), when receiving result.")
-		return nil, err
+// evalStream receives the garbled gates of the circuit chunk by chunk
+// and evaluates each chunk as it arrives.
+func evalStream(cfg *utils.Config, conn *ot.Conn, circ *Circuit,
+	key []byte, wires []ot.Label) error {
+
+	stream, err := circ.NewEvalStream(key, wires, cfg.Garbling)
+	if err != nil {
+		return err
+	}
+	stream.SetWorkers(cfg.Workers)
+	for !stream.Done() {
+		var chunk gateChunk
+		if err := conn.DirectRecv(&chunk, "garbled gates"); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::Evaluator(...), when receiving garbled gates.")
+			return err
+		}
+		if err := stream.evalChunk(&chunk); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::Evaluator(...), when evaluating gates.")
+			conn.Abort(ot.AbortEvaluation, err)
+			return err
+		}
 	}
-	return circ.Outputs.Split(&result), nil
+	return nil
 }
@@ -0,0 +1,623 @@
+//
+// fpre.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/ot/drbg"
+)
+
+// ErrAuthenticationFailed is returned when the peer's authenticated
+// bits or AND triples fail their MAC checks.
+var ErrAuthenticationFailed = errors.New("authentication failed")
+
+const (
+	// authSecurity specifies the statistical security parameter of
+	// the AND triple bucketing.
+	authSecurity = 40
+
+	// authChunk specifies the number of authenticated bits and AND
+	// triples that are processed in one message.
+	authChunk = 1 << 16
+)
+
+// authBit holds our share of an authenticated bit that is XOR shared
+// between the parties. The bit is our share and the mac is its MAC
+// under the peer's global key. The key is our key of the peer's share
+// under our global key: the peer's MAC is key ⊕ share·Δ.
+type authBit struct {
+	bit bool
+	mac ot.Label
+	key ot.Label
+}
+
+// xor returns the XOR of the authenticated bits.
+func (a authBit) xor(b authBit) authBit {
+	a.bit = a.bit != b.bit
+	a.mac.Xor(b.mac)
+	a.key.Xor(b.key)
+	return a
+}
+
+// scale returns the authenticated bit multiplied with the public
+// constant c.
+func (a authBit) scale(c bool) authBit {
+	if c {
+		return a
+	}
+	return authBit{}
+}
+
+// authTriple holds our shares of an authenticated AND triple z = x∧y.
+type authTriple struct {
+	x, y, z authBit
+}
+
+// authParty holds a party's state of the authenticated garbling
+// preprocessing. The cots authenticates the peer's bits under our
+// global key delta and the cotr authenticates our bits under the
+// peer's global key.
+type authParty struct {
+	party  Party
+	conn   *ot.Conn
+	rand   io.Reader
+	delta  ot.Label
+	hash   mmoHash
+	garble mmoHash
+	cots   *ot.COTSender
+	cotr   *ot.COTReceiver
+}
+
+// newAuthParty creates the party's preprocessing state. The parties
+// toss coins for the hash keys and set up the correlated OTs in both
+// directions.
+func newAuthParty(conn *ot.Conn, oti *ot.CO, rand io.Reader, party Party) (
+	*authParty, error) {
+
+	delta, err := ot.NewLabel(rand)
+	if err != nil {
+		return nil, err
+	}
+	p := &authParty{
+		party: party,
+		conn:  conn,
+		rand:  rand,
+		delta: delta,
+	}
+
+	// P1. 抛币: 协商哈希密钥
+	seed, err := p.coinToss("hash seed")
+	if err != nil {
+		return nil, err
+	}
+	keys, err := drbg.New(drbg.AESCTR, seed)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	if _, err := io.ReadFull(keys, key[:]); err != nil {
+		return nil, err
+	}
+	p.hash, err = newMMOHash(key[:])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(keys, key[:]); err != nil {
+		return nil, err
+	}
+	p.garble, err = newMMOHash(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// P2. 建立双向的 correlated OT
+	if party == PartyGarbler {
+		p.cots, err = ot.NewCOTSender(oti, conn, delta)
+		if err == nil {
+			p.cotr, err = ot.NewCOTReceiver(oti, conn)
+		}
+	} else {
+		p.cotr, err = ot.NewCOTReceiver(oti, conn)
+		if err == nil {
+			p.cots, err = ot.NewCOTSender(oti, conn, delta)
+		}
+	}
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when setting up correlated OT.")
+		return nil, err
+	}
+	return p, nil
+}
+
+// role returns the index of our role: 0 for the garbler and 1 for the
+// evaluator.
+func (p *authParty) role() int {
+	if p.party == PartyGarbler {
+		return 0
+	}
+	return 1
+}
+
+// xorConst returns the authenticated bit XORed with the public
+// constant c. The garbler adds the constant to its share and the
+// evaluator adjusts its key of the garbler's share.
+func (p *authParty) xorConst(a authBit, c bool) authBit {
+	if !c {
+		return a
+	}
+	if p.party == PartyGarbler {
+		a.bit = !a.bit
+	} else {
+		a.key.Xor(p.delta)
+	}
+	return a
+}
+
+// coinToss runs a coin-tossing protocol and returns a random seed
+// that neither party controls. The garbler commits to its seed before
+// the evaluator sends its seed.
+func (p *authParty) coinToss(topic string) ([]byte, error) {
+	ours := make([]byte, drbg.SeedSize)
+	if _, err := io.ReadFull(p.rand, ours); err != nil {
+		return nil, err
+	}
+	var peer []byte
+	var err error
+	if p.party == PartyGarbler {
+		commitment := sha256.Sum256(ours)
+		err = p.conn.DirectSend(commitment[:], topic+" commitment")
+		if err == nil {
+			err = p.conn.DirectRecv(&peer, topic)
+		}
+		if err == nil {
+			err = p.conn.DirectSend(ours, topic+" opening")
+		}
+	} else {
+		var commitment []byte
+		err = p.conn.DirectRecv(&commitment, topic+" commitment")
+		if err == nil {
+			err = p.conn.DirectSend(ours, topic)
+		}
+		if err == nil {
+			err = p.conn.DirectRecv(&peer, topic+" opening")
+		}
+		if err == nil {
+			sum := sha256.Sum256(peer)
+			if subtle.ConstantTimeCompare(sum[:], commitment) != 1 {
+				err = ErrAuthenticationFailed
+			}
+		}
+	}
+	if err != nil {
+		err = errors.Wrapf(err,
+			"in mpc_hd::AuthGarbling(...), when tossing %s.", topic)
+		return nil, err
+	}
+	if len(peer) != len(ours) {
+		return nil, fmt.Errorf("peer sent %d byte seed, expected %d",
+			len(peer), len(ours))
+	}
+	for i := range ours {
+		ours[i] ^= peer[i]
+	}
+	return ours, nil
+}
+
+// randomBits creates n random authenticated bits.
+func (p *authParty) randomBits(n int) ([]authBit, error) {
+	result := make([]authBit, 0, n)
+	for len(result) < n {
+		count := min(n-len(result), authChunk)
+		buf := make([]byte, (count+7)/8)
+		if _, err := io.ReadFull(p.rand, buf); err != nil {
+			return nil, err
+		}
+		choices := make([]bool, count)
+		for i := range choices {
+			choices[i] = buf[i/8]&(1<<(i%8)) != 0
+		}
+		var keys, macs []ot.Label
+		var err error
+		if p.party == PartyGarbler {
+			keys, err = p.cots.Send(count)
+			if err == nil {
+				macs, err = p.cotr.Receive(choices)
+			}
+		} else {
+			macs, err = p.cotr.Receive(choices)
+			if err == nil {
+				keys, err = p.cots.Send(count)
+			}
+		}
+		if err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::AuthGarbling(...), when authenticating bits.")
+			return nil, err
+		}
+		for i, c := range choices {
+			result = append(result, authBit{
+				bit: c,
+				mac: macs[i],
+				key: keys[i],
+			})
+		}
+	}
+	return result, nil
+}
+
+// authOpening opens our shares of authenticated bits to the peer. The
+// MAC is the SHA-256 digest of the MACs of the shares.
+type authOpening struct {
+	Bits []bool
+	MAC  []byte
+}
+
+// sendOpening opens our shares of the authenticated bits to the peer.
+func (p *authParty) sendOpening(shares []authBit, topic string) error {
+	var data ot.LabelData
+	for start := 0; start < len(shares); start += authChunk {
+		chunk := shares[start:min(start+authChunk, len(shares))]
+		h := sha256.New()
+		opening := authOpening{
+			Bits: make([]bool, len(chunk)),
+		}
+		for i, share := range chunk {
+			opening.Bits[i] = share.bit
+			h.Write(share.mac.Bytes(&data))
+		}
+		opening.MAC = h.Sum(nil)
+		if err := p.conn.DirectSend(&opening, topic); err != nil {
+			err = errors.Wrapf(err,
+				"in mpc_hd::AuthGarbling(...), when sending %s.", topic)
+			return err
+		}
+	}
+	return nil
+}
+
+// recvOpening receives the peer's opening of the authenticated bits
+// and verifies the peer's MACs. The function returns the opened bits.
+func (p *authParty) recvOpening(shares []authBit, topic string) (
+	[]bool, error) {
+
+	var data ot.LabelData
+	result := make([]bool, 0, len(shares))
+	for start := 0; start < len(shares); start += authChunk {
+		chunk := shares[start:min(start+authChunk, len(shares))]
+		var opening authOpening
+		if err := p.conn.DirectRecv(&opening, topic); err != nil {
+			err = errors.Wrapf(err,
+				"in mpc_hd::AuthGarbling(...), when receiving %s.", topic)
+			return nil, err
+		}
+		if len(opening.Bits) != len(chunk) {
+			return nil, fmt.Errorf("peer opened %d bits, expected %d",
+				len(opening.Bits), len(chunk))
+		}
+		h := sha256.New()
+		for i, share := range chunk {
+			mac := share.key
+			if opening.Bits[i] {
+				mac.Xor(p.delta)
+			}
+			h.Write(mac.Bytes(&data))
+			result = append(result, share.bit != opening.Bits[i])
+		}
+		if subtle.ConstantTimeCompare(h.Sum(nil), opening.MAC) != 1 {
+			return nil, ErrAuthenticationFailed
+		}
+	}
+	return result, nil
+}
+
+// open opens the authenticated bits to both parties.
+func (p *authParty) open(shares []authBit, topic string) ([]bool, error) {
+	if err := p.sendOpening(shares, topic); err != nil {
+		return nil, err
+	}
+	return p.recvOpening(shares, topic)
+}
+
+// authBucketSize returns the number of leaky AND triples that are
+// combined into one AND triple when creating n triples. The bucket
+// size B = ⌈s/log₂n⌉ + 1 bounds the probability that a bucket has
+// only leaky triples by 2^-s.
+func authBucketSize(n int) int {
+	l := max(bits.Len(uint(n))-1, 1)
+	return (authSecurity+l-1)/l + 1
+}
+
+// tripleBits returns the number of random authenticated bits that
+// triples needs for n AND triples.
+func tripleBits(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return 3 * n * authBucketSize(n)
+}
+
+// triples creates n authenticated AND triples from tripleBits(n)
+// random authenticated bits. The function creates leaky triples,
+// permutes them with a jointly random permutation, and combines the
+// buckets of leaky triples into the result triples. This is the
+// protocol of Wang, Ranellucci, and Katz: "Global-Scale Secure
+// Multiparty Computation", CCS 2017.
+func (p *authParty) triples(n int, shares []authBit) ([]authTriple, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	b := authBucketSize(n)
+	total := n * b
+	if len(shares) != 3*total {
+		return nil, fmt.Errorf("got %d bits for %d triples", len(shares), n)
+	}
+	leaky := make([]authTriple, 0, total)
+	for len(leaky) < total {
+		count := min(total-len(leaky), authChunk)
+		t, err := p.leakyTriples(len(leaky), shares[:3*count])
+		if err != nil {
+			return nil, err
+		}
+		shares = shares[3*count:]
+		leaky = append(leaky, t...)
+	}
+
+	// T5. 抛币: 置换 leaky 三元组并分桶
+	seed, err := p.coinToss("bucket seed")
+	if err != nil {
+		return nil, err
+	}
+	perm, err := authPermutation(seed, total)
+	if err != nil {
+		return nil, err
+	}
+
+	// T6. 合并: 打开 d = y ⊕ y', 得到 (x ⊕ x', y, z ⊕ z' ⊕ d·x').
+	// 合并不改变 y, 所以所有的 d 可以一次打开.
+	d := make([]authBit, 0, n*(b-1))
+	for i := 0; i < n; i++ {
+		first := leaky[perm[i*b]]
+		for k := 1; k < b; k++ {
+			d = append(d, first.y.xor(leaky[perm[i*b+k]].y))
+		}
+	}
+	opened, err := p.open(d, "bucket")
+	if err != nil {
+		return nil, err
+	}
+	result := make([]authTriple, n)
+	for i := range result {
+		acc := leaky[perm[i*b]]
+		for k := 1; k < b; k++ {
+			t := leaky[perm[i*b+k]]
+			acc.x = acc.x.xor(t.x)
+			acc.z = acc.z.xor(t.z).xor(t.x.scale(opened[i*(b-1)+k-1]))
+		}
+		result[i] = acc
+	}
+	return result, nil
+}
+
+// laandMsg holds the hashed MACs of the leaky AND check. The Own
+// values check the triples under the sender's global key and the Peer
+// values check them under the receiver's global key.
+type laandMsg struct {
+	Own  []ot.Label
+	Peer []ot.Label
+}
+
+// authTweak returns the hash tweak of the leaky AND triple j. The
+// slots 0 and 1 hash the half AND keys of the garbler and evaluator.
+// The slots 2-5 hash the check keys under the global key of the world
+// party, held by the keyholder party.
+func authTweak(j, slot int) uint32 {
+	return uint32(8*j + slot)
+}
+
+// leakyTriples creates leaky authenticated AND triples from the random
+// authenticated bits, three bits per triple. The triple indices start
+// from base. A malicious peer can guess the x bits of the triples but
+// a wrong guess fails the check.
+func (p *authParty) leakyTriples(base int, shares []authBit) (
+	[]authTriple, error) {
+
+	n := len(shares) / 3
+	x := shares[:n]
+	y := shares[n : 2*n]
+	r := shares[2*n:]
+	own := p.role()
+	peer := 1 - own
+
+	// T1. Half AND: 我方持有对方 y 的 key, 对方持有我方 y 的 key.
+	h := make([]ot.Label, 3*n)
+	tweaks := make([]uint32, 3*n)
+	for j := 0; j < n; j++ {
+		key := y[j].key
+		h[3*j] = key
+		key.Xor(p.delta)
+		h[3*j+1] = key
+		h[3*j+2] = y[j].mac
+		tweaks[3*j] = authTweak(base+j, own)
+		tweaks[3*j+1] = authTweak(base+j, own)
+		tweaks[3*j+2] = authTweak(base+j, peer)
+	}
+	p.hash.Hash(h, tweaks)
+	t := make([]bool, n)
+	v := make([]bool, n)
+	for j := 0; j < n; j++ {
+		t[j] = h[3*j].S() != h[3*j+1].S() != x[j].bit
+		v[j] = h[3*j].S() != h[3*j+2].S()
+	}
+
+	// T2. 检查值: 双方在各自的全局密钥下验证 (x₁ ⊕ x₂)(y₁ ⊕ y₂) = z₁ ⊕ z₂.
+	// 检查值不依赖于 z, 与 half AND 一起发送.
+	h = make([]ot.Label, 6*n)
+	tweaks = make([]uint32, 6*n)
+	for j := 0; j < n; j++ {
+		key := x[j].key
+		keyDelta := key
+		keyDelta.Xor(p.delta)
+		copy(h[6*j:], []ot.Label{
+			key, keyDelta, key, keyDelta, x[j].mac, x[j].mac,
+		})
+		copy(tweaks[6*j:], []uint32{
+			authTweak(base+j, 2+2*own+own),
+			authTweak(base+j, 2+2*own+own),
+			authTweak(base+j, 2+2*peer+own),
+			authTweak(base+j, 2+2*peer+own),
+			authTweak(base+j, 2+2*own+peer),
+			authTweak(base+j, 2+2*peer+peer),
+		})
+	}
+	p.hash.Hash(h, tweaks)
+	msg := laandMsg{
+		Own:  make([]ot.Label, n),
+		Peer: make([]ot.Label, n),
+	}
+	phi := make([]ot.Label, n)
+	for j := 0; j < n; j++ {
+		phi[j] = y[j].key
+		if y[j].bit {
+			phi[j].Xor(p.delta)
+		}
+		msg.Own[j] = h[6*j]
+		msg.Own[j].Xor(h[6*j+1])
+		msg.Own[j].Xor(phi[j])
+		msg.Peer[j] = h[6*j+2]
+		msg.Peer[j].Xor(h[6*j+3])
+		msg.Peer[j].Xor(y[j].mac)
+	}
+	var pt []bool
+	var pm laandMsg
+	err := p.conn.DirectSend(t, "half and")
+	if err == nil {
+		err = p.conn.DirectSend(&msg, "and check")
+	}
+	if err == nil {
+		err = p.conn.DirectRecv(&pt, "half and")
+	}
+	if err == nil {
+		err = p.conn.DirectRecv(&pm, "and check")
+	}
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when exchanging half and.")
+		return nil, err
+	}
+	if len(pt) != n || len(pm.Own) != n || len(pm.Peer) != n {
+		return nil, fmt.Errorf("peer sent %d/%d/%d half ands, expected %d",
+			len(pt), len(pm.Own), len(pm.Peer), n)
+	}
+
+	// T3. 认证 z: 打开 d = z ⊕ r, 令 [z] = [r] ⊕ d.
+	d := make([]bool, n)
+	for j := 0; j < n; j++ {
+		if y[j].bit {
+			v[j] = v[j] != pt[j]
+		}
+		z := v[j] != (x[j].bit && y[j].bit)
+		d[j] = z != r[j].bit
+	}
+	var pd []bool
+	err = p.conn.DirectSend(d, "leaky and")
+	if err == nil {
+		err = p.conn.DirectRecv(&pd, "leaky and")
+	}
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when exchanging leaky and.")
+		return nil, err
+	}
+	if len(pd) != n {
+		return nil, fmt.Errorf("peer sent %d leaky ands, expected %d",
+			len(pd), n)
+	}
+	result := make([]authTriple, n)
+	for j := range result {
+		result[j] = authTriple{
+			x: x[j],
+			y: y[j],
+			z: p.xorConst(r[j], d[j] != pd[j]),
+		}
+	}
+
+	// T4. 验证: 比较我方全局密钥下的检查值与对方计算的检查值.
+	var data ot.LabelData
+	ours := sha256.New()
+	theirs := sha256.New()
+	for j, triple := range result {
+		var s ot.Label
+		if triple.x.bit {
+			s = phi[j]
+			s.Xor(pm.Peer[j])
+		}
+		s.Xor(h[6*j])
+		s.Xor(h[6*j+4])
+		if triple.z.bit {
+			s.Xor(p.delta)
+		}
+		s.Xor(triple.z.key)
+		ours.Write(s.Bytes(&data))
+
+		s = ot.Label{}
+		if triple.x.bit {
+			s = triple.y.mac
+			s.Xor(pm.Own[j])
+		}
+		s.Xor(h[6*j+5])
+		s.Xor(h[6*j+2])
+		s.Xor(triple.z.mac)
+		theirs.Write(s.Bytes(&data))
+	}
+	var digest []byte
+	err = p.conn.DirectSend(theirs.Sum(nil), "and digest")
+	if err == nil {
+		err = p.conn.DirectRecv(&digest, "and digest")
+	}
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::AuthGarbling(...), when exchanging and digest.")
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(ours.Sum(nil), digest) != 1 {
+		return nil, ErrAuthenticationFailed
+	}
+	return result, nil
+}
+
+// authPermutation returns a random permutation of n elements drawn
+// from a DRBG seeded with the seed.
+func authPermutation(seed []byte, n int) ([]int, error) {
+	r, err := drbg.New(drbg.AESCTR, seed)
+	if err != nil {
+		return nil, err
+	}
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	var buf [8]byte
+	for i := n - 1; i > 0; i-- {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		j := int(binary.BigEndian.Uint64(buf[:]) % uint64(i+1))
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+	return perm, nil
+}
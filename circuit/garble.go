@@ -7,35 +7,15 @@
 package circuit
 
 import (
-	"crypto/aes"
 	"crypto/cipher"
 	"encoding/binary"
-	"fmt"
 	"io"
 
+	"github.com/markkurossi/mpc/compiler/utils"
 	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/ot/drbg"
 )
 
-func idxUnary(l0 ot.Label) int {
-	if l0.S() {
-		return 1
-	}
-	return 0
-}
-
-func idx(l0, l1 ot.Label) int {
-	var ret int
-
-	if l0.S() {
-		ret |= 0x2
-	}
-	if l1.S() {
-		ret |= 0x1
-	}
-
-	return ret
-}
-
 func encrypt(alg cipher.Block, a, b, c ot.Label, t uint32,
 	data *ot.LabelData) ot.Label {
 
@@ -181,21 +161,74 @@ func (g *Garbled) SetLambda(wire Wire, val uint) {
 	g.Wires[wire] = w
 }
 
-// Garble garbles the circuit.
+// Garble garbles the circuit. The wire labels are generated from the
+// argument source of randomness. If rand is nil, the labels are
+// generated with a DRBG seeded from the operating system.
 func (c *Circuit) Garble(rand io.Reader, key []byte) (*Garbled, error) {
-	// Create R.
-	r, err := ot.NewLabel(rand)
+	return c.garble(rand, key, utils.HalfGates, 1)
+}
+
+// GarbleParallel garbles the circuit like Garble but it garbles the
+// gates of each circuit level with the argument number of workers.
+// The garbled circuit is identical to the one Garble produces from the
+// same source of randomness.
+func (c *Circuit) GarbleParallel(rand io.Reader, key []byte, workers int) (
+	*Garbled, error) {
+	return c.garble(rand, key, utils.HalfGates, workers)
+}
+
+func (c *Circuit) garble(rand io.Reader, key []byte, scheme utils.Garbling,
+	workers int) (*Garbled, error) {
+
+	stream, err := c.NewGarbleStream(rand, key, scheme)
 	if err != nil {
 		return nil, err
 	}
-	r.SetS(true)
+	stream.SetWorkers(workers)
+	garbled, err := stream.Next(c.NumGates)
+	if err != nil {
+		return nil, err
+	}
+	result := stream.Garbled()
+	result.Gates = garbled
+
+	return result, nil
+}
+
+// GarbleStream garbles the circuit gate chunk by gate chunk so that
+// only one chunk of garbled gates is held in memory.
+type GarbleStream struct {
+	c       *Circuit
+	scheme  GarblingScheme
+	r       ot.Label
+	wires   []ot.Wire
+	id      uint32
+	next    int
+	data    ot.LabelData
+	workers int
+	sched   *schedule
+}
 
-	garbled := make([][]ot.Label, c.NumGates)
+// NewGarbleStream creates a new garble stream for the circuit with the
+// garbling scheme. The function creates R and the input wire labels
+// from the argument source of randomness. If rand is nil, the labels
+// are generated with a DRBG seeded from the operating system.
+func (c *Circuit) NewGarbleStream(rand io.Reader, key []byte,
+	scheme utils.Garbling) (*GarbleStream, error) {
 
-	alg, err := aes.NewCipher(key)
+	if rand == nil {
+		d, err := drbg.NewFromOS(drbg.AESCTR)
+		if err != nil {
+			return nil, err
+		}
+		rand = d
+	}
+	// Create R.
+	r, err := ot.NewLabel(rand)
 	if err != nil {
 		return nil, err
 	}
+	r.SetS(true)
 
 	// Wire labels.
 	wires := make([]ot.Wire, c.NumWires)
@@ -209,198 +242,78 @@ func (c *Circuit) Garble(rand io.Reader, key []byte) (*Garbled, error) {
 		wires[i] = w
 	}
 
-	// Garble gates.
-	var data ot.LabelData
-	var id uint32
-	for i := 0; i < len(c.Gates); i++ {
-		gate := &c.Gates[i]
-		data, err := gate.garble(wires, alg, r, &id, &data)
-		if err != nil {
-			return nil, err
-		}
-		garbled[i] = data
+	gs, err := NewGarblingScheme(scheme, key, rand)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Garbled{
-		R:     r,
-		Wires: wires,
-		Gates: garbled,
+	return &GarbleStream{
+		c:      c,
+		scheme: gs,
+		r:      r,
+		wires:  wires,
 	}, nil
 }
 
-// Garble garbles the gate and returns it labels.
-func (g *Gate) garble(wires []ot.Wire, enc cipher.Block, r ot.Label,
-	idp *uint32, data *ot.LabelData) ([]ot.Label, error) {
-
-	var a, b, c ot.Wire
-
-	var table [4]ot.Label
-	var start, count int
-
-	// Inputs.
-	switch g.Op {
-	case XOR, XNOR, AND, OR:
-		b = wires[g.Input1]
-		fallthrough
-
-	case INV:
-		a = wires[g.Input0]
-
-	default:
-		return nil, fmt.Errorf("invalid gate type %s", g.Op)
+// Garbled returns the garbling state without the garbled gates. The
+// labels of the gate output wires are set when the gates are garbled.
+func (s *GarbleStream) Garbled() *Garbled {
+	return &Garbled{
+		R:     s.r,
+		Wires: s.wires,
 	}
+}
 
-	// Output.
-	switch g.Op {
-	case XOR:
-		l0 := a.L0
-		l0.Xor(b.L0)
-
-		l1 := l0
-		l1.Xor(r)
-		c = ot.Wire{
-			L0: l0,
-			L1: l1,
-		}
-
-	case XNOR:
-		l0 := a.L0
-		l0.Xor(b.L0)
-
-		l1 := l0
-		l1.Xor(r)
-		c = ot.Wire{
-			L0: l1,
-			L1: l0,
-		}
-
-	case AND:
-		pa := a.L0.S()
-		pb := b.L0.S()
+// Done tests if all gates are garbled.
+func (s *GarbleStream) Done() bool {
+	return s.next >= len(s.c.Gates)
+}
 
-		j0 := *idp
-		j1 := *idp + 1
-		*idp = *idp + 2
+// Next garbles the next count gates and returns their garbled tables.
+// The function returns fewer gates at the end of the circuit.
+func (s *GarbleStream) Next(count int) ([][]ot.Label, error) {
+	count = min(count, len(s.c.Gates)-s.next)
+	garbled := make([][]ot.Label, count)
 
-		// First half gate.
-		tg := encryptHalf(enc, a.L0, j0, data)
-		tg.Xor(encryptHalf(enc, a.L1, j0, data))
-		if pb {
-			tg.Xor(r)
-		}
-		wg0 := encryptHalf(enc, a.L0, j0, data)
-		if pa {
-			wg0.Xor(tg)
-		}
-
-		// Second half gate.
-		te := encryptHalf(enc, b.L0, j1, data)
-		te.Xor(encryptHalf(enc, b.L1, j1, data))
-		te.Xor(a.L0)
-		we0 := encryptHalf(enc, b.L0, j1, data)
-		if pb {
-			we0.Xor(te)
-			we0.Xor(a.L0)
+	if s.workers > 1 {
+		err := s.parallel(s.next, garbled)
+		if err != nil {
+			return nil, err
 		}
-
-		// Combine halves
-		l0 := wg0
-		l0.Xor(we0)
-
-		l1 := l0
-		l1.Xor(r)
-
-		c = ot.Wire{
-			L0: l0,
-			L1: l1,
+		s.next += count
+		return garbled, nil
+	}
+	for i := 0; i < count; i++ {
+		data, err := s.garble(&s.c.Gates[s.next], &s.id, &s.data)
+		if err != nil {
+			return nil, err
 		}
-		table[0] = tg
-		table[1] = te
-		count = 2
-
-	case OR, INV:
-		// Row reduction creates labels below so that the first row is
-		// all zero.
+		garbled[i] = data
+		s.next++
+	}
+	return garbled, nil
+}
 
-	default:
-		panic("invalid gate type")
+// nextChunk garbles the next count gates and returns their garbled
+// tables in the wire encoding.
+func (s *GarbleStream) nextChunk(count int) (*gateChunk, error) {
+	from := s.next
+	tables, err := s.Next(count)
+	if err != nil {
+		return nil, err
 	}
+	return encodeTables(s.scheme, s.c.Gates[from:], tables)
+}
 
-	switch g.Op {
-	case XOR, XNOR:
-		// Free XOR.
-
-	case AND:
-		// Half AND garbled above.
-
-	case OR:
-		// a b c
-		// -----
-		// 0 0 0
-		// 0 1 1
-		// 1 0 1
-		// 1 1 1
-		id := *idp
-		*idp = *idp + 1
-		table[idx(a.L0, b.L0)] = encrypt(enc, a.L0, b.L0, c.L0, id, data)
-		table[idx(a.L0, b.L1)] = encrypt(enc, a.L0, b.L1, c.L1, id, data)
-		table[idx(a.L1, b.L0)] = encrypt(enc, a.L1, b.L0, c.L1, id, data)
-		table[idx(a.L1, b.L1)] = encrypt(enc, a.L1, b.L1, c.L1, id, data)
-
-		l0Index := idx(a.L0, b.L0)
-
-		c.L0 = table[0]
-		c.L1 = table[0]
-
-		if l0Index == 0 {
-			c.L1.Xor(r)
-		} else {
-			c.L0.Xor(r)
-		}
-		for i := 0; i < 4; i++ {
-			if i == l0Index {
-				table[i].Xor(c.L0)
-			} else {
-				table[i].Xor(c.L1)
-			}
-		}
-		start = 1
-		count = 3
-
-	case INV:
-		// a b c
-		// -----
-		// 0   1
-		// 1   0
-		id := *idp
-		*idp = *idp + 1
-		table[idxUnary(a.L0)] = encrypt(enc, a.L0, ot.Label{}, c.L1, id, data)
-		table[idxUnary(a.L1)] = encrypt(enc, a.L1, ot.Label{}, c.L0, id, data)
-
-		l0Index := idxUnary(a.L0)
-
-		c.L0 = table[0]
-		c.L1 = table[0]
-
-		if l0Index == 0 {
-			c.L0.Xor(r)
-		} else {
-			c.L1.Xor(r)
-		}
-		for i := 0; i < 2; i++ {
-			if i == l0Index {
-				table[i].Xor(c.L1)
-			} else {
-				table[i].Xor(c.L0)
-			}
-		}
-		start = 1
-		count = 1
+// garble garbles the gate with the stream's garbling scheme.
+func (s *GarbleStream) garble(g *Gate, idp *uint32, data *ot.LabelData) (
+	[]ot.Label, error) {
 
-	default:
-		return nil, fmt.Errorf("invalid operand %s", g.Op)
+	table, err := s.scheme.Garble(g, s.wires, s.r, *idp, data)
+	if err != nil {
+		return nil, err
 	}
-	wires[g.Output] = c
+	*idp += s.scheme.Tweaks(g.Op)
 
-	return table[start : start+count], nil
+	return table, nil
 }
@@ -10,6 +10,7 @@ package circuit
 
 import (
 	"fmt"
+	"io"
 	"math/big"
 
 	"github.com/cockroachdb/errors"
@@ -34,7 +35,16 @@ func (s FileSize) String() string {
 	}
 }
 
-// Garbler runs the garbler on the P2P network.
+// Garbler runs the garbler on the P2P network. If the garbler
+// fails, it aborts the protocol so that the evaluator fails immediately.
+// The garbler draws all its randomness, including the OT
+// randomness, from a session DRBG seeded from the configuration. If
+// the configuration has a checkpoint store, the garbler resumes the
+// session from its checkpoint.
+//
+// If the configuration selects the AuthGarbling protocol, the garbler
+// runs the maliciously secure authenticated garbling protocol. The
+// protocol supports one circuit instance without checkpoints.
 func Garbler(
 	cfg *utils.Config,
 	conn *ot.Conn,
@@ -45,55 +55,338 @@ func Garbler(
 ) (
 	[]*big.Int, error,
 ) {
-	rand := cfg.GetRandom()
-	if verbose {
-		fmt.Printf(" - Garbling...\n")
+	results, err := GarblerBatch(cfg, conn, oti, circ, []*big.Int{inputs},
+		verbose)
+	if err != nil {
+		return nil, err
 	}
+	return results[0], nil
+}
+
+// GarblerBatch runs the garbler for a batch of circuit instances in
+// one session. The circuit is garbled once for each input set and the
+// instances share the protocol rounds and the OT setup. The function
+// returns the results in the order of the input sets. The evaluator
+// must run EvaluatorBatch with the same number of input sets.
+func GarblerBatch(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs []*big.Int,
+	verbose bool,
+) (
+	[][]*big.Int, error,
+) {
+	results, err := garbler(cfg, conn, oti, circ, inputs, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return results, err
+}
+
+// gateChunkSize specifies the number of garbled gates in one
+// streamed message.
+const gateChunkSize = 1 << 16
+
+// garblerInstance holds the garbler's state of a garbled circuit
+// instance.
+type garblerInstance struct {
+	inputs  []ot.Wire
+	outputs []ot.Wire
+	masks   *big.Int
+	shares  []*big.Int
+	stream  *GarbleStream
+}
+
+// evaluatorInstance holds the garbled circuit instance the garbler
+// sends to the evaluator. The gates and decoding are nil if they are
+// streamed in the online phase.
+type evaluatorInstance struct {
+	Key      [32]byte
+	Gates    [][]ot.Label
+	Decoding []OutputDecoding
+}
 
-	var key [32]byte
-	_, err := rand.Read(key[:])
+// newInstances creates n instances of the circuit with their garble
+// streams. The function returns the circuit with expanded output
+// shares, and the garbler's and evaluator's states of the instances.
+func newInstances(cfg *utils.Config, rand io.Reader, circ *Circuit, n int) (
+	*Circuit, []*garblerInstance, []*evaluatorInstance, error) {
+
+	// Mask the additively shared outputs with garbler's mask inputs.
+	shared, err := circ.expandShares()
 	if err != nil {
+		return nil, nil, nil, err
+	}
+	ginsts := make([]*garblerInstance, n)
+	einsts := make([]*evaluatorInstance, n)
+	for i := range ginsts {
+		masks, shares, err := circ.shareMasks(rand)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		masks.Lsh(masks, uint(circ.Inputs[0].Type.Bits))
+		ginsts[i] = &garblerInstance{
+			masks:  masks,
+			shares: shares,
+		}
+	}
+	circ = shared
+
+	for i, ginst := range ginsts {
+		einst := new(evaluatorInstance)
+		_, err = rand.Read(einst.Key[:])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ginst.stream, err = circ.NewGarbleStream(rand, einst.Key[:],
+			cfg.Garbling)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ginst.stream.SetWorkers(cfg.Workers)
+		wires := ginst.stream.Garbled().Wires
+		ginst.inputs = wires[:circ.Inputs.Size()]
+		ginst.outputs = wires[circ.NumWires-circ.Outputs.Size():]
+		einsts[i] = einst
+	}
+	return circ, ginsts, einsts, nil
+}
+
+// garbleInstances garbles n instances of the circuit. The function
+// returns the circuit with expanded output shares, and the garbler's
+// and evaluator's states of the instances.
+func garbleInstances(cfg *utils.Config, rand io.Reader, circ *Circuit,
+	n int) (*Circuit, []*garblerInstance, []*evaluatorInstance, error) {
+
+	circ, ginsts, einsts, err := newInstances(cfg, rand, circ, n)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i, ginst := range ginsts {
+		einsts[i].Gates, err = ginst.stream.Next(circ.NumGates)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if cfg.OutputMode == utils.OutputDecode {
+			einsts[i].Decoding = ginst.stream.Garbled().OutputDecoding(circ)
+		}
+		ginst.stream = nil
+	}
+	return circ, ginsts, einsts, nil
+}
+
+func garbler(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inputs []*big.Int,
+	verbose bool,
+) (
+	[][]*big.Int, error,
+) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no input sets")
+	}
+	if err := circ.checkOutputMode(cfg.OutputMode); err != nil {
+		return nil, err
+	}
+	if cfg.Protocol == utils.AuthGarbling {
+		if len(inputs) != 1 {
+			return nil, fmt.Errorf("authenticated garbling batches not supported")
+		}
+		result, err := authGarbling(cfg, conn, oti, circ, PartyGarbler,
+			inputs[0], verbose)
+		if err != nil {
+			return nil, err
+		}
+		return [][]*big.Int{result}, nil
+	}
+
+	sess, rand, err := newSession(cfg, conn, circ, PartyGarbler)
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Garbler(...), when loading checkpoint.")
+		return nil, err
+	}
+	oti = oti.WithRandom(rand)
+
+	// G0. 握手: 确认协议版本, 电路和实例数一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyGarbler,
+		Instances: len(inputs),
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Garbler(...), when exchanging handshake.")
+		return nil, err
+	}
+	if err := sess.step(0); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Garbler(...), when saving checkpoint.")
 		return nil, err
 	}
 
-	garbled, err := circ.Garble(rand, key[:])
+	circ, ginsts, einsts, err := newInstances(cfg, rand, circ, len(inputs))
 	if err != nil {
 		return nil, err
 	}
 
 	// G1. 发送临时密钥
-	if verbose {
-		fmt.Printf(" - Sending garbled circuit...\n")
+	keys := make([][32]byte, len(einsts))
+	for i, einst := range einsts {
+		keys[i] = einst.Key
 	}
-	if err := conn.DirectSend(key, "ephemeral key"); err != nil {
+	if err := conn.DirectSend(keys, "ephemeral key"); err != nil {
 		err = errors.Wrap(err,
 			"in mpc_hd::Garbler(...), when sending ephemeral key.")
 		return nil, err
 	}
 
-	// G2. 发送 gates
-	if err := conn.DirectSend(garbled.Gates, "garbled gates"); err != nil {
+	return garblerOnline(cfg, conn, oti, sess, circ, ginsts, inputs, verbose)
+}
+
+// garblerOnline runs the online phase of the garbler for the garbled
+// instances. The circuit must have its output shares expanded. If the
+// instances have garble streams, the function garbles and sends the
+// gates and output decoding after the OT.
+func garblerOnline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	sess *session,
+	circ *Circuit,
+	insts []*garblerInstance,
+	inputs []*big.Int,
+	verbose bool,
+) (
+	[][]*big.Int, error,
+) {
+	err := garblerSend(cfg, conn, oti, sess, circ, insts, inputs, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	// G7. 接收结果 labels
+	var labels [][]ot.Label
+	if err := conn.DirectRecv(&labels, "result labels"); err != nil {
 		err = errors.Wrap(err,
-			"in mpc_hd::Garbler(...), when sending garbled gates.")
+			"in mpc_hd::Garbler(...), when receiving ot labels")
 		return nil, err
 	}
+	if len(labels) != len(insts) {
+		return nil, fmt.Errorf("peer sent result labels for %d instances, "+
+			"expected %d", len(labels), len(insts))
+	}
 
-	// G3. 发送 inputs
-	var wires []ot.Label
-	for i := 0; i < int(circ.Inputs[0].Type.Bits); i++ {
-		wire := garbled.Wires[i]
-		n := LabelForBit(wire, inputs.Bit(i) == 1)
-		wires = append(wires, n)
+	// G8. 解码结果. OutputGarbler 模式下发送双方可见的结果
+	mask := circ.Outputs.Mask(PartyGarbler)
+	results := make([]*big.Int, len(insts))
+	for idx, inst := range insts {
+		if len(labels[idx]) != circ.Outputs.Size() {
+			return nil, fmt.Errorf("peer sent %d result labels, expected %d",
+				len(labels[idx]), circ.Outputs.Size())
+		}
+		result := big.NewInt(0)
+		for i := 0; i < circ.Outputs.Size(); i++ {
+			if !mask[i] {
+				continue
+			}
+			boolBit, err := BitFromLabel(inst.outputs[i], labels[idx][i])
+			if err != nil {
+				err = errors.Wrap(err,
+					"in mpc_hd::Garbler(...), when extracting a bit from each label")
+				return nil, err
+			}
+			if boolBit {
+				result = big.NewInt(0).SetBit(result, i, 1)
+			}
+		}
+		results[idx] = result
+	}
+	if cfg.OutputMode == utils.OutputGarbler {
+		revealed := make([]*big.Int, len(results))
+		for idx, result := range results {
+			revealed[idx] = big.NewInt(0)
+			for i, reveal := range circ.Outputs.Mask(PartyEvaluator) {
+				if reveal {
+					revealed[idx].SetBit(revealed[idx], i, result.Bit(i))
+				}
+			}
+		}
+		if err := conn.DirectSend(revealed, "result"); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::Garbler(...), when sending result")
+			return nil, err
+		}
+	}
+
+	// G9. 确认通信记录
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Garbler(...), when confirming transcript")
+		return nil, err
+	}
+	if err := sess.done(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Garbler(...), when removing checkpoint.")
+		return nil, err
+	}
+
+	// 秘密分享输出: XOR 分享为输出线的置换位.
+	outputs := make([][]*big.Int, len(insts))
+	for idx, inst := range insts {
+		var permute []ot.Label
+		for _, wire := range inst.outputs {
+			permute = append(permute, wire.L0)
+		}
+		values := circ.Outputs.Split(results[idx])
+		values = circ.Outputs.Hide(values, PartyGarbler)
+		values = setShares(values, circ.xorShares(permute))
+		outputs[idx] = setShares(values, inst.shares)
+	}
+	return outputs, nil
+}
+
+// garblerSend sends the garbled instances to the evaluator: the
+// garbler's input labels, the evaluator's input labels with OT, and
+// the garbled gates and output decoding of the streamed instances.
+func garblerSend(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	sess *session,
+	circ *Circuit,
+	insts []*garblerInstance,
+	inputs []*big.Int,
+	verbose bool,
+) error {
+	// G2. 发送 inputs
+	wires := make([][]ot.Label, len(insts))
+	for i, inst := range insts {
+		value := new(big.Int).Or(inputs[i], inst.masks)
+		for bit := 0; bit < int(circ.Inputs[0].Type.Bits); bit++ {
+			n := LabelForBit(inst.inputs[bit], value.Bit(bit) == 1)
+			wires[i] = append(wires[i], n)
+		}
 	}
 	if err := conn.DirectSend(wires, "inputs"); err != nil {
 		err = errors.Wrap(err, "in mpc_hd::Garbler(...), when sending inputs.")
+		return err
+	}
+	if err := sess.step(2); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::Garbler(...), when saving checkpoint.")
+		return err
 	}
 
 	if verbose {
 		fmt.Printf(" - Processing messages...\n")
 	}
 
-	// G4. 接收 offset 和 count
+	// G3. 接收 offset 和 count
 	type OtQuery struct {
 		Offset int
 		Count  int
@@ -102,48 +395,68 @@ func Garbler(
 	if err := conn.DirectRecv(&query, "ot query"); err != nil {
 		err = errors.Wrap(err,
 			"in mpc_hd::Garbler(...), when receiving ot query")
-		return nil, err
+		return err
 	}
 	if query.Offset != int(circ.Inputs[0].Type.Bits) ||
 		query.Count != int(circ.Inputs[1].Type.Bits) {
-		return nil, fmt.Errorf("peer can't OT wires [%d..%d]",
+		return fmt.Errorf("peer can't OT wires [%d..%d]",
 			query.Offset, query.Offset+query.Count)
 	}
 
-	// G5. 执行 ot 发送. 位于 ot/co.go: func Send
-	err = oti.Send(garbled.Wires[query.Offset:query.Offset+query.Count], conn)
-	if err != nil {
-		return nil, err
+	// G4. 执行 ot 发送. 位于 ot/co.go: func Send. 所有实例共用一次 OT.
+	var otWires []ot.Wire
+	for _, inst := range insts {
+		otWires = append(otWires,
+			inst.inputs[query.Offset:query.Offset+query.Count]...)
 	}
-
-	// G6. 接收结果 labels
-	var labels []ot.Label
-	if err := conn.DirectRecv(&labels, "result labels"); err != nil {
+	if err := oti.Send(otWires, conn); err != nil {
+		return err
+	}
+	if err := sess.step(4); err != nil {
 		err = errors.Wrap(err,
-			"in mpc_hd::Garbler(...), when receiving ot labels")
-		return nil, err
+			"in mpc_hd::Garbler(...), when saving checkpoint.")
+		return err
 	}
 
-	// G7. 发送结果
-	result := big.NewInt(0)
-	for i := 0; i < circ.Outputs.Size(); i++ {
-		label := labels[i]
-		wire := garbled.Wires[circ.NumWires-circ.Outputs.Size()+i]
-		boolBit, err := BitFromLabel(wire, label)
-		if err != nil {
-			err = errors.Wrap(err,
-				"in mpc_hd::Garbler(...), when extracting a bit from each label")
-			return nil, err
+	// G5. 逐块混淆并发送 gates. 评估方收到后即开始评估.
+	if verbose {
+		fmt.Printf(" - Garbling and sending %d instance(s)...\n", len(insts))
+	}
+	var decoding [][]OutputDecoding
+	for _, inst := range insts {
+		if inst.stream == nil {
+			continue
+		}
+		for !inst.stream.Done() {
+			chunk, err := inst.stream.nextChunk(gateChunkSize)
+			if err != nil {
+				return err
+			}
+			if err := conn.DirectSend(chunk, "garbled gates"); err != nil {
+				err = errors.Wrap(err,
+					"in mpc_hd::Garbler(...), when sending garbled gates.")
+				return err
+			}
 		}
-		if boolBit {
-			result = big.NewInt(0).SetBit(result, i, 1)
+		if cfg.OutputMode == utils.OutputDecode {
+			decoding = append(decoding,
+				inst.stream.Garbled().OutputDecoding(circ))
+		}
+		inst.stream = nil
+	}
+
+	// G6. 发送输出解码信息
+	if decoding != nil {
+		if err := conn.DirectSend(decoding, "output decoding"); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::Garbler(...), when sending output decoding.")
+			return err
 		}
 	}
-	if err := conn.DirectSend(result, "result"); err != nil {
+	if err := sess.step(6); err != nil {
 		err = errors.Wrap(err,
-			"in mpc_hd::Garbler(...), when sending result")
-		return nil, err
+			"in mpc_hd::Garbler(...), when saving checkpoint.")
+		return err
 	}
-
-	return circ.Outputs.Split(result), nil
+	return nil
 }
@@ -0,0 +1,431 @@
+//
+// garbling.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// GarblingScheme implements a gate garbling scheme. The scheme
+// defines the garbled table of each gate operation and the tweaks the
+// gate consumes from the tweak counter. All schemes use free XOR: the
+// labels of each wire differ by the garbler's global offset R. The
+// schemes are safe for concurrent use with different gates.
+type GarblingScheme interface {
+	// ID returns the scheme identifier that the garbler and
+	// evaluator negotiate in the protocol handshake.
+	ID() utils.Garbling
+
+	// TableSize returns the number of labels in the garbled table of
+	// the gate operation.
+	TableSize(op Operation) int
+
+	// TableBytes returns the number of bytes the garbled table of the
+	// gate operation takes on the wire. The wire encoding holds the
+	// first TableBytes bytes of the table's labels.
+	TableBytes(op Operation) int
+
+	// Tweaks returns the number of tweaks the gate operation
+	// consumes.
+	Tweaks(op Operation) uint32
+
+	// Hash hashes the labels x with their tweaks and stores the
+	// hashes into x. The labels of one call are hashed together.
+	Hash(x []ot.Label, tweaks []uint32)
+
+	// Garble garbles the gate with the first tweak of the gate. The
+	// function sets the labels of the gate's output wire and returns
+	// the garbled table.
+	Garble(g *Gate, wires []ot.Wire, r ot.Label, tweak uint32,
+		data *ot.LabelData) ([]ot.Label, error)
+
+	// Eval evaluates the gate's garbled table with the first tweak
+	// of the gate and sets the label of the gate's output wire.
+	Eval(g *Gate, wires []ot.Label, row []ot.Label, tweak uint32,
+		data *ot.LabelData) error
+}
+
+// NewGarblingScheme creates the garbling scheme with the session key.
+// The garbler's schemes read their secret randomness from rand. The
+// evaluator's schemes are created with nil rand and they can only
+// evaluate gates.
+func NewGarblingScheme(scheme utils.Garbling, key []byte, rand io.Reader) (
+	GarblingScheme, error) {
+
+	h, err := newMMOHash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scheme {
+	case utils.HalfGates:
+		return &halfGates{
+			mmoHash: h,
+		}, nil
+
+	case utils.ThreeHalves:
+		// Secret key for the random bits of the AND gates.
+		ctrl, err := newSecretCipher(rand)
+		if err != nil {
+			return nil, err
+		}
+		return &threeHalves{
+			mmoHash: h,
+			ctrl:    ctrl,
+		}, nil
+
+	case utils.Classic:
+		// Secret key for the output wire labels.
+		out, err := newSecretCipher(rand)
+		if err != nil {
+			return nil, err
+		}
+		return &classic{
+			mmoHash: h,
+			out:     out,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported garbling scheme %v", scheme)
+	}
+}
+
+// newSecretCipher creates an AES cipher with a random key from rand.
+// The function returns nil cipher if rand is nil.
+func newSecretCipher(rand io.Reader) (cipher.Block, error) {
+	if rand == nil {
+		return nil, nil
+	}
+	var key [16]byte
+	if _, err := io.ReadFull(rand, key[:]); err != nil {
+		return nil, err
+	}
+	return aes.NewCipher(key[:])
+}
+
+// andScheme implements the garbling of AND gates. The schemes built on
+// andScheme garble the other gates for free and the OR gates as AND
+// gates of the inverted inputs: a∨b = ¬(¬a∧¬b).
+type andScheme interface {
+	garbleAND(a, b ot.Wire, r ot.Label, tweak uint32,
+		data *ot.LabelData) (ot.Wire, []ot.Label)
+	evalAND(a, b ot.Label, row []ot.Label, tweak uint32,
+		data *ot.LabelData) ot.Label
+}
+
+// garbleFree garbles the free XOR, XNOR, and INV gates. The function
+// returns false if the gate is not free.
+func garbleFree(g *Gate, wires []ot.Wire, r ot.Label) bool {
+	switch g.Op {
+	case XOR, XNOR:
+		l0 := wires[g.Input0].L0
+		l0.Xor(wires[g.Input1].L0)
+		l1 := l0
+		l1.Xor(r)
+
+		if g.Op == XOR {
+			wires[g.Output] = ot.Wire{
+				L0: l0,
+				L1: l1,
+			}
+		} else {
+			wires[g.Output] = ot.Wire{
+				L0: l1,
+				L1: l0,
+			}
+		}
+		return true
+
+	case INV:
+		// The output wire swaps the input labels.
+		wires[g.Output] = wires[g.Input0].Not()
+		return true
+
+	default:
+		return false
+	}
+}
+
+// evalFree evaluates the free XOR, XNOR, and INV gates. The function
+// returns false if the gate is not free.
+func evalFree(g *Gate, wires []ot.Label) bool {
+	switch g.Op {
+	case XOR, XNOR:
+		l := wires[g.Input0]
+		l.Xor(wires[g.Input1])
+		wires[g.Output] = l
+		return true
+
+	case INV:
+		// The inverted wire has the same labels as its input.
+		wires[g.Output] = wires[g.Input0]
+		return true
+
+	default:
+		return false
+	}
+}
+
+// garbleWithAND garbles the gate with the AND scheme.
+func garbleWithAND(s andScheme, g *Gate, wires []ot.Wire, r ot.Label,
+	tweak uint32, data *ot.LabelData) ([]ot.Label, error) {
+
+	if garbleFree(g, wires, r) {
+		return nil, nil
+	}
+	switch g.Op {
+	case AND:
+		c, table := s.garbleAND(wires[g.Input0], wires[g.Input1], r, tweak,
+			data)
+		wires[g.Output] = c
+		return table, nil
+
+	case OR:
+		c, table := s.garbleAND(wires[g.Input0].Not(), wires[g.Input1].Not(),
+			r, tweak, data)
+		wires[g.Output] = c.Not()
+		return table, nil
+
+	default:
+		return nil, fmt.Errorf("invalid gate type %s", g.Op)
+	}
+}
+
+// evalWithAND evaluates the gate with the AND scheme. The labels of
+// the inverted wires are the labels of the OR gate's inputs and output
+// so the OR gate evaluates as an AND gate.
+func evalWithAND(s andScheme, size int, g *Gate, wires []ot.Label,
+	row []ot.Label, tweak uint32, data *ot.LabelData) error {
+
+	if evalFree(g, wires) {
+		if len(row) != 0 {
+			return fmt.Errorf("corrupted ciruit: %s row length: %d",
+				g.Op, len(row))
+		}
+		return nil
+	}
+	switch g.Op {
+	case AND, OR:
+		if len(row) != size {
+			return fmt.Errorf("corrupted ciruit: %s row length: %d",
+				g.Op, len(row))
+		}
+		wires[g.Output] = s.evalAND(wires[g.Input0], wires[g.Input1], row,
+			tweak, data)
+		return nil
+
+	default:
+		return fmt.Errorf("invalid operation %s", g.Op)
+	}
+}
+
+// halfGates implements the half-gates scheme of Zahur, Rosulek, and
+// Evans: "Two Halves Make a Whole: Reducing Data Transfer in Garbled
+// Circuits using Half Gates", EUROCRYPT 2015.
+type halfGates struct {
+	mmoHash
+}
+
+// ID implements GarblingScheme.ID.
+func (s *halfGates) ID() utils.Garbling {
+	return utils.HalfGates
+}
+
+// TableSize implements GarblingScheme.TableSize.
+func (s *halfGates) TableSize(op Operation) int {
+	switch op {
+	case AND, OR:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// TableBytes implements GarblingScheme.TableBytes.
+func (s *halfGates) TableBytes(op Operation) int {
+	return s.TableSize(op) * 16
+}
+
+// Tweaks implements GarblingScheme.Tweaks.
+func (s *halfGates) Tweaks(op Operation) uint32 {
+	switch op {
+	case AND, OR:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Garble implements GarblingScheme.Garble.
+func (s *halfGates) Garble(g *Gate, wires []ot.Wire, r ot.Label,
+	tweak uint32, data *ot.LabelData) ([]ot.Label, error) {
+	return garbleWithAND(s, g, wires, r, tweak, data)
+}
+
+// Eval implements GarblingScheme.Eval.
+func (s *halfGates) Eval(g *Gate, wires []ot.Label, row []ot.Label,
+	tweak uint32, data *ot.LabelData) error {
+	return evalWithAND(s, 2, g, wires, row, tweak, data)
+}
+
+func (s *halfGates) garbleAND(a, b ot.Wire, r ot.Label, tweak uint32,
+	data *ot.LabelData) (ot.Wire, []ot.Label) {
+
+	pa := a.L0.S()
+	pb := b.L0.S()
+
+	j0 := tweak
+	j1 := tweak + 1
+
+	h := [4]ot.Label{a.L0, a.L1, b.L0, b.L1}
+	s.Hash(h[:], []uint32{j0, j0, j1, j1})
+
+	// First half gate.
+	tg := h[0]
+	tg.Xor(h[1])
+	if pb {
+		tg.Xor(r)
+	}
+	wg0 := h[0]
+	if pa {
+		wg0.Xor(tg)
+	}
+
+	// Second half gate.
+	te := h[2]
+	te.Xor(h[3])
+	te.Xor(a.L0)
+	we0 := h[2]
+	if pb {
+		we0.Xor(te)
+		we0.Xor(a.L0)
+	}
+
+	// Combine halves
+	l0 := wg0
+	l0.Xor(we0)
+
+	l1 := l0
+	l1.Xor(r)
+
+	return ot.Wire{
+		L0: l0,
+		L1: l1,
+	}, []ot.Label{tg, te}
+}
+
+func (s *halfGates) evalAND(a, b ot.Label, row []ot.Label, tweak uint32,
+	data *ot.LabelData) ot.Label {
+
+	sa := a.S()
+	sb := b.S()
+
+	j0 := tweak
+	j1 := tweak + 1
+
+	tg := row[0]
+	te := row[1]
+
+	h := [2]ot.Label{a, b}
+	s.Hash(h[:], []uint32{j0, j1})
+
+	wg := h[0]
+	if sa {
+		wg.Xor(tg)
+	}
+	we := h[1]
+	if sb {
+		we.Xor(te)
+		we.Xor(a)
+	}
+	wg.Xor(we)
+
+	return wg
+}
+
+// gateChunk holds the garbled tables of consecutive gates in their wire
+// encoding.
+type gateChunk struct {
+	Count  int
+	Tables []byte
+}
+
+// encodeTables encodes the garbled tables of the gates with the
+// garbling scheme.
+func encodeTables(gs GarblingScheme, gates []Gate, tables [][]ot.Label) (
+	*gateChunk, error) {
+
+	var size int
+	for i := range tables {
+		size += gs.TableBytes(gates[i].Op)
+	}
+	chunk := &gateChunk{
+		Count:  len(tables),
+		Tables: make([]byte, 0, size),
+	}
+	var data ot.LabelData
+	for i, table := range tables {
+		n := gs.TableBytes(gates[i].Op)
+		if n > len(table)*len(data) {
+			return nil, fmt.Errorf("invalid %s table length: %d",
+				gates[i].Op, len(table))
+		}
+		for _, l := range table {
+			l.GetData(&data)
+			m := min(n, len(data))
+			chunk.Tables = append(chunk.Tables, data[:m]...)
+			n -= m
+		}
+	}
+	return chunk, nil
+}
+
+// decode decodes the garbled tables of the gates with the garbling
+// scheme. The gates must have at least chunk.Count gates.
+func (chunk *gateChunk) decode(gs GarblingScheme, gates []Gate) (
+	[][]ot.Label, error) {
+
+	if chunk.Count <= 0 || chunk.Count > len(gates) {
+		return nil, fmt.Errorf("invalid garbled gates chunk: %d gates",
+			chunk.Count)
+	}
+	tables := make([][]ot.Label, chunk.Count)
+	data := chunk.Tables
+	var buf ot.LabelData
+	for i := range tables {
+		n := gs.TableBytes(gates[i].Op)
+		if n > len(data) {
+			return nil, fmt.Errorf("truncated garbled gates chunk")
+		}
+		if n == 0 {
+			continue
+		}
+		table := make([]ot.Label, gs.TableSize(gates[i].Op))
+		for j := range table {
+			m := min(n, len(buf))
+			buf = ot.LabelData{}
+			copy(buf[:], data[:m])
+			table[j].SetData(&buf)
+			data = data[m:]
+			n -= m
+		}
+		tables[i] = table
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("garbled gates chunk has %d extra bytes",
+			len(data))
+	}
+	return tables, nil
+}
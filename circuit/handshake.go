@@ -0,0 +1,176 @@
+//
+// handshake.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"slices"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// ProtocolVersion specifies the version of the garbler and evaluator
+// protocol. The version must be incremented when the protocol messages
+// change.
+const ProtocolVersion = 9
+
+var (
+	// ErrProtocolMismatch is returned when the peers run incompatible
+	// protocol versions or configurations.
+	ErrProtocolMismatch = errors.New("protocol mismatch")
+
+	// ErrCircuitMismatch is returned when the peers have different
+	// circuits.
+	ErrCircuitMismatch = errors.New("circuit mismatch")
+)
+
+// Digest computes a canonical hash of the circuit. The hash covers
+// the circuit in the MPCL circuit format, including the names, types,
+// and attributes of the I/O arguments.
+func (c *Circuit) Digest() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.marshal(&buf, true); err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(buf.Bytes())
+	return h[:], nil
+}
+
+// handshake holds the protocol parameters the peers must agree on.
+// The Garbling lists the garbling schemes the party accepts in the
+// order of preference and the Public holds the values of the public
+// inputs the circuit was compiled with.
+type handshake struct {
+	Version    uint32
+	Party      Party
+	Circuit    []byte
+	Inputs     string
+	Outputs    string
+	Public     map[string]*big.Int
+	OutputMode utils.OutputMode
+	Protocol   utils.Protocol
+	Garbling   []utils.Garbling
+	Instances  int
+	Phase      string
+	Instance   string
+}
+
+// Protocol phases. The full protocol garbles and evaluates the circuit
+// in the same session. The offline phase garbles circuit instances in
+// advance and the online phase evaluates one pre-garbled instance.
+const (
+	phaseFull    = ""
+	phaseOffline = "offline"
+	phaseOnline  = "online"
+)
+
+// acceptGarbling returns the garbling schemes the configuration
+// accepts in the order of preference.
+func acceptGarbling(cfg *utils.Config) []utils.Garbling {
+	if len(cfg.AcceptGarbling) > 0 {
+		return cfg.AcceptGarbling
+	}
+	return []utils.Garbling{cfg.Garbling}
+}
+
+// exchangeHandshake sends our protocol parameters to the peer and
+// verifies that the peer uses the same protocol, public inputs, and
+// circuit. The argument handshake specifies our party and the
+// protocol run: the peers must run the same phase with the same number
+// of circuit instances. If the handshake does not list the garbling
+// schemes, the function uses the schemes of the configuration. The
+// function returns the session configuration with the negotiated
+// garbling scheme.
+func exchangeHandshake(cfg *utils.Config, conn *ot.Conn,
+	circ *Circuit, ours handshake) (*utils.Config, error) {
+
+	digest, err := circ.Digest()
+	if err != nil {
+		return nil, err
+	}
+	ours.Version = ProtocolVersion
+	ours.Circuit = digest
+	ours.Inputs = circ.Inputs.String()
+	ours.Outputs = circ.Outputs.String()
+	ours.Public = circ.Public
+	ours.OutputMode = cfg.OutputMode
+	ours.Protocol = cfg.Protocol
+	if len(ours.Garbling) == 0 {
+		ours.Garbling = acceptGarbling(cfg)
+	}
+
+	if err := conn.DirectSend(ours, "handshake"); err != nil {
+		return nil, err
+	}
+	var peer handshake
+	if err := conn.DirectRecv(&peer, "handshake"); err != nil {
+		return nil, err
+	}
+
+	if peer.Version != ours.Version {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"protocol version %d, peer has %d", ours.Version, peer.Version)
+	}
+	if peer.OutputMode != ours.OutputMode {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"output mode %v, peer has %v", ours.OutputMode, peer.OutputMode)
+	}
+	if peer.Protocol != ours.Protocol {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"protocol %v, peer has %v", ours.Protocol, peer.Protocol)
+	}
+	if peer.Phase != ours.Phase {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"phase %q, peer has %q", ours.Phase, peer.Phase)
+	}
+	if peer.Instance != ours.Instance {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"instance %q, peer has %q", ours.Instance, peer.Instance)
+	}
+	if peer.Instances != ours.Instances {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"%d instances, peer has %d", ours.Instances, peer.Instances)
+	}
+	// The public inputs are folded into the circuit so check them
+	// before the circuit digest to report the specific mismatch.
+	if err := comparePublicInputs(ours.Public, peer.Public); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(peer.Circuit, ours.Circuit) {
+		return nil, errors.Wrapf(ErrCircuitMismatch,
+			"circuit %x (%s) -> (%s), peer has %x (%s) -> (%s)",
+			ours.Circuit[:8], ours.Inputs, ours.Outputs,
+			peer.Circuit[:min(len(peer.Circuit), 8)],
+			peer.Inputs, peer.Outputs)
+	}
+
+	garbler, evaluator := ours.Garbling, peer.Garbling
+	switch {
+	case ours.Party == PartyEvaluator && peer.Party == PartyGarbler:
+		garbler, evaluator = evaluator, garbler
+	case ours.Party != PartyGarbler || peer.Party != PartyEvaluator:
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"party %v, peer is %v", ours.Party, peer.Party)
+	}
+	idx := slices.IndexFunc(garbler, func(g utils.Garbling) bool {
+		return slices.Contains(evaluator, g)
+	})
+	if idx < 0 {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"garbling schemes %v, peer has %v", ours.Garbling, peer.Garbling)
+	}
+	session := *cfg
+	session.Garbling = garbler[idx]
+
+	return &session, nil
+}
@@ -0,0 +1,65 @@
+//
+// hash.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"encoding/binary"
+
+	"github.com/markkurossi/mpc/circuit/aesni"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// maxHashBatch specifies the maximum number of labels that are hashed
+// with one AES call.
+const maxHashBatch = 8
+
+// mmoHash implements the tweakable hash H(x, i) = π(K) ⊕ K where
+// K = σ(x) ⊕ i. The π is AES with the fixed instance key and σ is the
+// linear orthomorphism σ(x_L ‖ x_R) = (x_L ⊕ x_R) ‖ x_L. This is the
+// MMO construction of Guo et al.: "Efficient and Secure Multiparty
+// Computation from Fixed-Key Block Ciphers", IEEE S&P 2020. The hash
+// encrypts the labels of a call together with one AES call.
+type mmoHash struct {
+	alg *aesni.Cipher
+}
+
+func newMMOHash(key []byte) (mmoHash, error) {
+	alg, err := aesni.New(key)
+	if err != nil {
+		return mmoHash{}, err
+	}
+	return mmoHash{
+		alg: alg,
+	}, nil
+}
+
+// Hash implements GarblingScheme.Hash.
+func (h mmoHash) Hash(x []ot.Label, tweaks []uint32) {
+	var buf [maxHashBatch * aesni.BlockSize]byte
+
+	for len(x) > 0 {
+		n := min(len(x), maxHashBatch)
+		for i := 0; i < n; i++ {
+			k := ot.Label{
+				D0: x[i].D0 ^ x[i].D1,
+				D1: x[i].D0 ^ uint64(tweaks[i]),
+			}
+			x[i] = k
+			binary.BigEndian.PutUint64(buf[16*i:], k.D0)
+			binary.BigEndian.PutUint64(buf[16*i+8:], k.D1)
+		}
+		h.alg.Encrypt(buf[:16*n], buf[:16*n])
+		for i := 0; i < n; i++ {
+			x[i].D0 ^= binary.BigEndian.Uint64(buf[16*i:])
+			x[i].D1 ^= binary.BigEndian.Uint64(buf[16*i+8:])
+		}
+		x = x[n:]
+		tweaks = tweaks[n:]
+	}
+}
@@ -64,11 +64,88 @@ func (io IO) Split(in *big.Int) []*big.Int {
 	return result
 }
 
+// Mask returns a bit mask of the I/O arguments that are revealed to
+// the party.
+func (io IO) Mask(party Party) []bool {
+	var result []bool
+	for _, arg := range io {
+		reveal := arg.RevealedTo(party)
+		for i := 0; i < int(arg.Type.Bits); i++ {
+			result = append(result, reveal)
+		}
+	}
+	return result
+}
+
+// Hide sets the values of the I/O arguments that are not revealed to
+// the party to nil.
+func (io IO) Hide(values []*big.Int, party Party) []*big.Int {
+	for idx, arg := range io {
+		if idx < len(values) && !arg.RevealedTo(party) {
+			values[idx] = nil
+		}
+	}
+	return values
+}
+
+// Party identifies the protocol parties. As an output assignment,
+// PartyBoth reveals the output to both garbler and evaluator.
+type Party byte
+
+// Protocol parties.
+const (
+	PartyBoth Party = iota
+	PartyGarbler
+	PartyEvaluator
+)
+
+var partyNames = map[Party]string{
+	PartyBoth:      "both",
+	PartyGarbler:   "garbler",
+	PartyEvaluator: "evaluator",
+}
+
+func (p Party) String() string {
+	name, ok := partyNames[p]
+	if ok {
+		return name
+	}
+	return fmt.Sprintf("{Party %d}", p)
+}
+
+// ParseParty parses the party name.
+func ParseParty(name string) (Party, error) {
+	for p, n := range partyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return PartyBoth, fmt.Errorf("invalid party: %s", name)
+}
+
 // IOArg describes circuit input argument.
 type IOArg struct {
 	Name     string
 	Type     types.Info
 	Compound IO
+
+	// Party specifies the parties that learn the output value.
+	Party Party
+
+	// Share specifies how the output value is shared between the
+	// parties. Modulus is the public modulus of additive shares.
+	Share   Share
+	Modulus *big.Int
+}
+
+// RevealedTo tests if the argument value is revealed to the party.
+// The values of the shared arguments are not revealed to either
+// party.
+func (io IOArg) RevealedTo(party Party) bool {
+	if io.Share != ShareNone {
+		return false
+	}
+	return io.Party == PartyBoth || io.Party == party
 }
 
 func (io IOArg) String() string {
@@ -10,11 +10,16 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math/big"
 )
 
 const (
 	// MAGIC is a magic number for the MPCL circuit format version 0.
 	MAGIC = 0x63726300 // crc0
+
+	// MAGIC1 is a magic number for the MPCL circuit format version
+	// 1. The version 1 adds attributes to the I/O arguments.
+	MAGIC1 = 0x63726301 // crc1
 )
 
 var (
@@ -35,8 +40,20 @@ func (c *Circuit) MarshalFormat(out io.Writer, format string) error {
 
 // Marshal marshals circuit in the MPCL circuit format.
 func (c *Circuit) Marshal(out io.Writer) error {
+	return c.marshal(out,
+		c.Inputs.hasAttributes() || c.Outputs.hasAttributes())
+}
+
+// marshal marshals the circuit in the MPCL circuit format. The attrs
+// argument selects the format version 1 with the I/O argument
+// attributes.
+func (c *Circuit) marshal(out io.Writer, attrs bool) error {
+	magic := uint32(MAGIC)
+	if attrs {
+		magic = MAGIC1
+	}
 	var data = []interface{}{
-		uint32(MAGIC),
+		magic,
 		uint32(c.NumGates),
 		uint32(c.NumWires),
 		uint32(len(c.Inputs)),
@@ -48,12 +65,12 @@ func (c *Circuit) Marshal(out io.Writer) error {
 		}
 	}
 	for _, input := range c.Inputs {
-		if err := marshalIOArg(out, input); err != nil {
+		if err := marshalIOArg(out, input, attrs); err != nil {
 			return err
 		}
 	}
 	for _, output := range c.Outputs {
-		if err := marshalIOArg(out, output); err != nil {
+		if err := marshalIOArg(out, output, attrs); err != nil {
 			return err
 		}
 	}
@@ -83,7 +100,7 @@ func (c *Circuit) Marshal(out io.Writer) error {
 	return nil
 }
 
-func marshalIOArg(out io.Writer, arg IOArg) error {
+func marshalIOArg(out io.Writer, arg IOArg, attrs bool) error {
 	if err := marshalString(out, arg.Name); err != nil {
 		return err
 	}
@@ -97,13 +114,80 @@ func marshalIOArg(out io.Writer, arg IOArg) error {
 		return err
 	}
 	for _, c := range arg.Compound {
-		if err := marshalIOArg(out, c); err != nil {
+		if err := marshalIOArg(out, c, attrs); err != nil {
+			return err
+		}
+	}
+	if !attrs {
+		return nil
+	}
+	attributes := arg.attributes()
+	if err := binary.Write(out, bo, uint32(len(attributes))); err != nil {
+		return err
+	}
+	for _, attr := range attributes {
+		if err := marshalString(out, attr[0]); err != nil {
+			return err
+		}
+		if err := marshalString(out, attr[1]); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func (io IO) hasAttributes() bool {
+	for _, arg := range io {
+		if len(arg.attributes()) > 0 || arg.Compound.hasAttributes() {
+			return true
+		}
+	}
+	return false
+}
+
+// attributes returns the non-default attributes of the I/O argument
+// as key-value pairs.
+func (arg IOArg) attributes() [][2]string {
+	var result [][2]string
+	if arg.Party != PartyBoth {
+		result = append(result, [2]string{"party", arg.Party.String()})
+	}
+	if arg.Share != ShareNone {
+		result = append(result, [2]string{"share", arg.Share.String()})
+	}
+	if arg.Modulus != nil {
+		result = append(result, [2]string{"modulus", arg.Modulus.Text(16)})
+	}
+	return result
+}
+
+// setAttribute sets the I/O argument attribute.
+func (arg *IOArg) setAttribute(key, value string) error {
+	switch key {
+	case "party":
+		party, err := ParseParty(value)
+		if err != nil {
+			return err
+		}
+		arg.Party = party
+	case "share":
+		share, err := ParseShare(value)
+		if err != nil {
+			return err
+		}
+		arg.Share = share
+	case "modulus":
+		modulus, ok := new(big.Int).SetString(value, 16)
+		if !ok {
+			return fmt.Errorf("invalid modulus: %s", value)
+		}
+		arg.Modulus = modulus
+	default:
+		return fmt.Errorf("unknown I/O argument attribute: %s", key)
+	}
+	return nil
+}
+
 func marshalString(out io.Writer, val string) error {
 	bytes := []byte(val)
 	if err := binary.Write(out, bo, uint32(len(bytes))); err != nil {
@@ -0,0 +1,508 @@
+//
+// offline.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// ErrUnknownInstance is returned if the inventory does not have the
+// pre-garbled circuit instance.
+var ErrUnknownInstance = errors.New("unknown circuit instance")
+
+// Inventory stores pre-garbled circuit instances by instance ID. The
+// garbler and evaluator keep their own inventories. An instance is
+// removed from the inventory when its online phase starts and it is
+// never reused, even if the online phase fails.
+type Inventory struct {
+	m     sync.Mutex
+	items map[string]*inventoryItem
+}
+
+type inventoryItem struct {
+	party     Party
+	circuit   []byte
+	mode      utils.OutputMode
+	garbling  utils.Garbling
+	garbler   *garblerInstance
+	evaluator *evaluatorInstance
+}
+
+// NewInventory creates a new empty inventory.
+func NewInventory() *Inventory {
+	return &Inventory{
+		items: make(map[string]*inventoryItem),
+	}
+}
+
+// Len returns the number of instances in the inventory.
+func (inv *Inventory) Len() int {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+	return len(inv.items)
+}
+
+// IDs returns the sorted IDs of the instances in the inventory.
+func (inv *Inventory) IDs() []string {
+	inv.m.Lock()
+	defer inv.m.Unlock()
+
+	var ids []string
+	for id := range inv.items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (inv *Inventory) add(id string, item *inventoryItem) {
+	inv.m.Lock()
+	inv.items[id] = item
+	inv.m.Unlock()
+}
+
+// take removes the instance from the inventory and verifies that it
+// was garbled for the party, circuit, and output mode, and with a
+// garbling scheme the configuration accepts.
+func (inv *Inventory) take(id string, party Party, digest []byte,
+	cfg *utils.Config) (*inventoryItem, error) {
+
+	inv.m.Lock()
+	item, ok := inv.items[id]
+	delete(inv.items, id)
+	inv.m.Unlock()
+
+	if !ok || item.party != party {
+		return nil, errors.Wrapf(ErrUnknownInstance, "%s", id)
+	}
+	if !bytes.Equal(item.circuit, digest) {
+		return nil, errors.Wrapf(ErrCircuitMismatch,
+			"instance %s garbled for circuit %x", id, item.circuit[:8])
+	}
+	if item.mode != cfg.OutputMode {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"instance %s garbled for output mode %v", id, item.mode)
+	}
+	if !slices.Contains(acceptGarbling(cfg), item.garbling) {
+		return nil, errors.Wrapf(ErrProtocolMismatch,
+			"instance %s garbled with %v", id, item.garbling)
+	}
+	return item, nil
+}
+
+// offlineInstance is a pre-garbled circuit instance. The gates hold
+// the garbled tables of all gates in their wire encoding.
+type offlineInstance struct {
+	ID       string
+	Key      [32]byte
+	Gates    *gateChunk
+	Decoding []OutputDecoding
+}
+
+// GarblerOffline runs the offline phase of the garbler. It garbles
+// count instances of the circuit, sends them to the evaluator, and
+// stores the garbler's state of the instances into the inventory. The
+// function returns the IDs of the garbled instances. The evaluator
+// must run EvaluatorOffline with the same count.
+func GarblerOffline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	circ *Circuit,
+	inv *Inventory,
+	count int,
+	verbose bool,
+) (
+	[]string, error,
+) {
+	ids, err := garblerOffline(cfg, conn, circ, inv, count, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return ids, err
+}
+
+func garblerOffline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	circ *Circuit,
+	inv *Inventory,
+	count int,
+	verbose bool,
+) (
+	[]string, error,
+) {
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid instance count %d", count)
+	}
+	if cfg.Protocol != utils.SemiHonest {
+		return nil, fmt.Errorf("offline phase not supported with protocol %v",
+			cfg.Protocol)
+	}
+	if err := circ.checkOutputMode(cfg.OutputMode); err != nil {
+		return nil, err
+	}
+	digest, err := circ.Digest()
+	if err != nil {
+		return nil, err
+	}
+	rand, err := sessionRandom(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	// O0. 握手: 确认协议版本, 电路和实例数一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyGarbler,
+		Instances: count,
+		Phase:     phaseOffline,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerOffline(...), when exchanging handshake.")
+		return nil, err
+	}
+
+	if verbose {
+		fmt.Printf(" - Garbling %d instance(s)...\n", count)
+	}
+	expanded, ginsts, einsts, err := garbleInstances(cfg, rand, circ, count)
+	if err != nil {
+		return nil, err
+	}
+
+	// O1. 逐个发送预混淆实例
+	ids := make([]string, count)
+	for i, einst := range einsts {
+		var buf [16]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return nil, err
+		}
+		ids[i] = fmt.Sprintf("%x", buf)
+		gs, err := NewGarblingScheme(cfg.Garbling, einst.Key[:], nil)
+		if err != nil {
+			return nil, err
+		}
+		gates, err := encodeTables(gs, expanded.Gates, einst.Gates)
+		if err != nil {
+			return nil, err
+		}
+		err = conn.DirectSend(&offlineInstance{
+			ID:       ids[i],
+			Key:      einst.Key,
+			Gates:    gates,
+			Decoding: einst.Decoding,
+		}, "offline instance")
+		if err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::GarblerOffline(...), when sending instance.")
+			return nil, err
+		}
+	}
+
+	// O2. 确认通信记录. 确认后才保存实例.
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerOffline(...), when confirming transcript.")
+		return nil, err
+	}
+	for i, ginst := range ginsts {
+		inv.add(ids[i], &inventoryItem{
+			party:    PartyGarbler,
+			circuit:  digest,
+			mode:     cfg.OutputMode,
+			garbling: cfg.Garbling,
+			garbler:  ginst,
+		})
+	}
+	return ids, nil
+}
+
+// EvaluatorOffline runs the offline phase of the evaluator. It
+// receives count pre-garbled instances of the circuit from the garbler
+// and stores them into the inventory. The function returns the IDs of
+// the received instances.
+func EvaluatorOffline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	circ *Circuit,
+	inv *Inventory,
+	count int,
+	verbose bool,
+) (
+	[]string, error,
+) {
+	ids, err := evaluatorOffline(cfg, conn, circ, inv, count, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return ids, err
+}
+
+func evaluatorOffline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	circ *Circuit,
+	inv *Inventory,
+	count int,
+	verbose bool,
+) (
+	[]string, error,
+) {
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid instance count %d", count)
+	}
+	if cfg.Protocol != utils.SemiHonest {
+		return nil, fmt.Errorf("offline phase not supported with protocol %v",
+			cfg.Protocol)
+	}
+	if err := circ.checkOutputMode(cfg.OutputMode); err != nil {
+		return nil, err
+	}
+	digest, err := circ.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	// O0. 握手: 确认协议版本, 电路和实例数一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyEvaluator,
+		Instances: count,
+		Phase:     phaseOffline,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorOffline(...), when exchanging handshake.")
+		return nil, err
+	}
+	expanded, err := circ.expandShares()
+	if err != nil {
+		return nil, err
+	}
+
+	// O1. 逐个接收预混淆实例
+	if verbose {
+		fmt.Printf(" - Receiving %d instance(s)...\n", count)
+	}
+	insts := make([]*offlineInstance, count)
+	gates := make([][][]ot.Label, count)
+	for i := range insts {
+		inst := new(offlineInstance)
+		if err := conn.DirectRecv(inst, "offline instance"); err != nil {
+			err = errors.Wrap(err,
+				"in mpc_hd::EvaluatorOffline(...), when receiving instance.")
+			return nil, err
+		}
+		if len(inst.ID) == 0 || inst.Gates == nil ||
+			inst.Gates.Count != expanded.NumGates {
+			return nil, fmt.Errorf("peer sent invalid instance %q", inst.ID)
+		}
+		gs, err := NewGarblingScheme(cfg.Garbling, inst.Key[:], nil)
+		if err != nil {
+			return nil, err
+		}
+		gates[i], err = inst.Gates.decode(gs, expanded.Gates)
+		if err != nil {
+			return nil, fmt.Errorf("peer sent invalid instance %q: %v",
+				inst.ID, err)
+		}
+		if cfg.OutputMode == utils.OutputDecode &&
+			len(inst.Decoding) != expanded.Outputs.Size() {
+			return nil, fmt.Errorf("peer sent instance %q without decoding",
+				inst.ID)
+		}
+		insts[i] = inst
+	}
+
+	// O2. 确认通信记录. 确认后才保存实例.
+	if err := conn.ConfirmTranscript(); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorOffline(...), when confirming transcript.")
+		return nil, err
+	}
+	ids := make([]string, count)
+	for i, inst := range insts {
+		ids[i] = inst.ID
+		inv.add(inst.ID, &inventoryItem{
+			party:    PartyEvaluator,
+			circuit:  digest,
+			mode:     cfg.OutputMode,
+			garbling: cfg.Garbling,
+			evaluator: &evaluatorInstance{
+				Key:      inst.Key,
+				Gates:    gates[i],
+				Decoding: inst.Decoding,
+			},
+		})
+	}
+	return ids, nil
+}
+
+// GarblerOnline runs the online phase of the garbler for the
+// pre-garbled instance id. The online phase sends only the garbler's
+// input labels, runs the OT for the evaluator's inputs, and decodes
+// the outputs. The evaluator must run EvaluatorOnline for the same
+// instance.
+func GarblerOnline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inv *Inventory,
+	id string,
+	inputs *big.Int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	result, err := garblerOnlinePhase(cfg, conn, oti, circ, inv, id, inputs,
+		verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return result, err
+}
+
+func garblerOnlinePhase(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inv *Inventory,
+	id string,
+	inputs *big.Int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	if err := circ.checkOutputMode(cfg.OutputMode); err != nil {
+		return nil, err
+	}
+	digest, err := circ.Digest()
+	if err != nil {
+		return nil, err
+	}
+	item, err := inv.take(id, PartyGarbler, digest, cfg)
+	if err != nil {
+		return nil, err
+	}
+	rand, err := sessionRandom(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+	oti = oti.WithRandom(rand)
+
+	// G0. 握手: 确认协议版本, 电路和实例一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyGarbler,
+		Garbling:  []utils.Garbling{item.garbling},
+		Instances: 1,
+		Phase:     phaseOnline,
+		Instance:  id,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::GarblerOnline(...), when exchanging handshake.")
+		return nil, err
+	}
+	expanded, err := circ.expandShares()
+	if err != nil {
+		return nil, err
+	}
+	results, err := garblerOnline(cfg, conn, oti, nil, expanded,
+		[]*garblerInstance{item.garbler}, []*big.Int{inputs}, verbose)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// EvaluatorOnline runs the online phase of the evaluator for the
+// pre-garbled instance id.
+func EvaluatorOnline(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inv *Inventory,
+	id string,
+	inputs *big.Int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	result, err := evaluatorOnlinePhase(cfg, conn, oti, circ, inv, id,
+		inputs, verbose)
+	if err != nil && conn != nil && shouldAbort(cfg, err) {
+		conn.Abort(abortCode(err), err)
+	}
+	return result, err
+}
+
+func evaluatorOnlinePhase(
+	cfg *utils.Config,
+	conn *ot.Conn,
+	oti *ot.CO,
+	circ *Circuit,
+	inv *Inventory,
+	id string,
+	inputs *big.Int,
+	verbose bool,
+) (
+	[]*big.Int, error,
+) {
+	if err := circ.checkOutputMode(cfg.OutputMode); err != nil {
+		return nil, err
+	}
+	digest, err := circ.Digest()
+	if err != nil {
+		return nil, err
+	}
+	item, err := inv.take(id, PartyEvaluator, digest, cfg)
+	if err != nil {
+		return nil, err
+	}
+	rand, err := sessionRandom(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+	oti = oti.WithRandom(rand)
+
+	// E0. 握手: 确认协议版本, 电路和实例一致
+	cfg, err = exchangeHandshake(cfg, conn, circ, handshake{
+		Party:     PartyEvaluator,
+		Garbling:  []utils.Garbling{item.garbling},
+		Instances: 1,
+		Phase:     phaseOnline,
+		Instance:  id,
+	})
+	if err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::EvaluatorOnline(...), when exchanging handshake.")
+		return nil, err
+	}
+	expanded, err := circ.expandShares()
+	if err != nil {
+		return nil, err
+	}
+	results, err := evaluatorOnline(cfg, conn, oti, nil, expanded,
+		[]*evaluatorInstance{item.evaluator}, []*big.Int{inputs}, verbose)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
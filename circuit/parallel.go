@@ -0,0 +1,182 @@
+//
+// parallel.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"sync"
+
+	"github.com/markkurossi/mpc/ot"
+)
+
+// minParallelWidth specifies the minimum number of gates in a level
+// for processing the level with multiple workers. The narrower levels
+// are processed sequentially since the synchronization would cost more
+// than the parallel garbling saves.
+const minParallelWidth = 256
+
+// schedule assigns the gates to levels and precomputes the gate
+// tweaks. The tweaks are assigned in the gate order, identical to
+// sequential garbling, so the parallel and sequential garbling produce
+// the same garbled tables.
+type schedule struct {
+	levels []Level
+	tweaks []uint32
+}
+
+// newSchedule creates the level schedule for the circuit and garbling
+// scheme. The function does not modify the circuit's gate levels.
+func newSchedule(c *Circuit, scheme GarblingScheme) *schedule {
+	wireLevels := make([]Level, c.NumWires)
+	s := &schedule{
+		levels: make([]Level, len(c.Gates)),
+		tweaks: make([]uint32, len(c.Gates)+1),
+	}
+	var id uint32
+	for idx, gate := range c.Gates {
+		level := wireLevels[gate.Input0]
+		if gate.Op != INV {
+			level = max(level, wireLevels[gate.Input1])
+		}
+		s.levels[idx] = level
+		wireLevels[gate.Output] = level + 1
+
+		s.tweaks[idx] = id
+		id += scheme.Tweaks(gate.Op)
+	}
+	s.tweaks[len(c.Gates)] = id
+
+	return s
+}
+
+// run calls fn for the gates [from, to) level by level. The gates of
+// one level are independent and fn is called for them concurrently
+// from the workers. Each worker has its own label data buffer.
+func (s *schedule) run(from, to, workers int,
+	fn func(gate int, data *ot.LabelData) error) error {
+
+	if from >= to {
+		return nil
+	}
+
+	// Bucket the gates by their level, keeping the gate order within
+	// each level.
+	lo, hi := s.levels[from], s.levels[from]
+	for _, l := range s.levels[from:to] {
+		lo = min(lo, l)
+		hi = max(hi, l)
+	}
+	start := make([]int, hi-lo+2)
+	for _, l := range s.levels[from:to] {
+		start[l-lo+1]++
+	}
+	for i := 1; i < len(start); i++ {
+		start[i] += start[i-1]
+	}
+	pos := append([]int(nil), start...)
+	order := make([]int, to-from)
+	for i := from; i < to; i++ {
+		l := s.levels[i] - lo
+		order[pos[l]] = i
+		pos[l]++
+	}
+
+	data := make([]ot.LabelData, workers)
+
+	for l := 0; l < len(start)-1; l++ {
+		gates := order[start[l]:start[l+1]]
+		if len(gates) < minParallelWidth || workers < 2 {
+			for _, g := range gates {
+				if err := fn(g, &data[0]); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, workers)
+		size := (len(gates) + workers - 1) / workers
+
+		for w := 0; w < workers; w++ {
+			part := gates[min(w*size, len(gates)):min((w+1)*size, len(gates))]
+			if len(part) == 0 {
+				break
+			}
+			wg.Add(1)
+			go func(w int, part []int) {
+				defer wg.Done()
+				for _, g := range part {
+					if err := fn(g, &data[w]); err != nil {
+						errs[w] = err
+						return
+					}
+				}
+			}(w, part)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetWorkers sets the number of workers that garble the gates of each
+// circuit level in parallel. The values 0 and 1 garble the gates
+// sequentially.
+func (s *GarbleStream) SetWorkers(workers int) {
+	s.workers = workers
+	if workers > 1 && s.sched == nil {
+		s.sched = newSchedule(s.c, s.scheme)
+	}
+}
+
+func (s *GarbleStream) parallel(from int, garbled [][]ot.Label) error {
+	to := from + len(garbled)
+	err := s.sched.run(from, to, s.workers,
+		func(idx int, data *ot.LabelData) error {
+			id := s.sched.tweaks[idx]
+			table, err := s.garble(&s.c.Gates[idx], &id, data)
+			if err != nil {
+				return err
+			}
+			garbled[idx-from] = table
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	s.id = s.sched.tweaks[to]
+	return nil
+}
+
+// SetWorkers sets the number of workers that evaluate the gates of
+// each circuit level in parallel. The values 0 and 1 evaluate the
+// gates sequentially.
+func (s *EvalStream) SetWorkers(workers int) {
+	s.workers = workers
+	if workers > 1 && s.sched == nil {
+		s.sched = newSchedule(s.c, s.scheme)
+	}
+}
+
+func (s *EvalStream) parallel(from int, garbled [][]ot.Label) error {
+	to := from + len(garbled)
+	err := s.sched.run(from, to, s.workers,
+		func(idx int, data *ot.LabelData) error {
+			id := s.sched.tweaks[idx]
+			return s.eval(&s.c.Gates[idx], garbled[idx-from], &id, data)
+		})
+	if err != nil {
+		return err
+	}
+	s.id = s.sched.tweaks[to]
+	return nil
+}
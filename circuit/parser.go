@@ -81,13 +81,21 @@ func ParseMPCLC(in io.Reader) (*Circuit, error) {
 	if err := binary.Read(r, bo, &header); err != nil {
 		return nil, err
 	}
+	var attrs bool
+	switch header.Magic {
+	case MAGIC:
+	case MAGIC1:
+		attrs = true
+	default:
+		return nil, fmt.Errorf("invalid circuit magic: %08x", header.Magic)
+	}
 	var inputs, outputs IO
 	var inputWires, outputWires int
 
 	wiresSeen := make(Seen, header.NumWires)
 
 	for i := 0; i < int(header.NumInputs); i++ {
-		arg, err := parseIOArg(r)
+		arg, err := parseIOArg(r, attrs)
 		if err != nil {
 			return nil, err
 		}
@@ -95,7 +103,7 @@ func ParseMPCLC(in io.Reader) (*Circuit, error) {
 		inputWires += int(arg.Type.Bits)
 	}
 	for i := 0; i < int(header.NumOutputs); i++ {
-		out, err := parseIOArg(r)
+		out, err := parseIOArg(r, attrs)
 		if err != nil {
 			return nil, err
 		}
@@ -210,7 +218,7 @@ func ParseMPCLC(in io.Reader) (*Circuit, error) {
 	}, nil
 }
 
-func parseIOArg(r *bufio.Reader) (arg IOArg, err error) {
+func parseIOArg(r *bufio.Reader, attrs bool) (arg IOArg, err error) {
 	name, err := parseString(r)
 	if err != nil {
 		return arg, err
@@ -235,12 +243,33 @@ func parseIOArg(r *bufio.Reader) (arg IOArg, err error) {
 		return arg, err
 	}
 	for i := 0; i < int(ui32); i++ {
-		c, err := parseIOArg(r)
+		c, err := parseIOArg(r, attrs)
 		if err != nil {
 			return arg, err
 		}
 		arg.Compound = append(arg.Compound, c)
 	}
+	if !attrs {
+		return
+	}
+
+	// Attributes
+	if err := binary.Read(r, bo, &ui32); err != nil {
+		return arg, err
+	}
+	for i := 0; i < int(ui32); i++ {
+		key, err := parseString(r)
+		if err != nil {
+			return arg, err
+		}
+		value, err := parseString(r)
+		if err != nil {
+			return arg, err
+		}
+		if err := arg.setAttribute(key, value); err != nil {
+			return arg, err
+		}
+	}
 
 	return
 }
@@ -26,3 +26,24 @@ func TestParse(t *testing.T) {
 		t.Fatalf("Parse failed: %s", err)
 	}
 }
+
+func TestParseMPCLCAttributes(t *testing.T) {
+	circ, err := ParseBristol(bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+	circ.Outputs[0].Party = PartyEvaluator
+
+	var buf bytes.Buffer
+	if err := circ.Marshal(&buf); err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	parsed, err := ParseMPCLC(&buf)
+	if err != nil {
+		t.Fatalf("ParseMPCLC failed: %s", err)
+	}
+	if parsed.Outputs[0].Party != PartyEvaluator {
+		t.Errorf("output party: got %v, expected %v",
+			parsed.Outputs[0].Party, PartyEvaluator)
+	}
+}
@@ -0,0 +1,1252 @@
+//
+// protocol_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markkurossi/mpc/circuit"
+	"github.com/markkurossi/mpc/compiler"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/ot/drbg"
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const protocolTestCode = `
+package main
+
+func main(g, e uint32) (uint32, uint32) {
+    return g + e, g * e
+}
+`
+
+// startMessenger starts an in-process messenger server and returns
+// its address.
+func startMessenger(t *testing.T, server pb.MpcSessionManagerServer) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterMpcSessionManagerServer(srv, server)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func compileProtocolTest(t *testing.T, code string) *circuit.Circuit {
+	t.Helper()
+	params := utils.NewParams()
+	params.OptPruneGates = true
+	circ, _, err := compiler.New(params).Compile(code, nil)
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	return circ
+}
+
+type protocolResult struct {
+	result []*big.Int
+	err    error
+}
+
+// decodeConfig returns a configuration that publishes the output
+// decoding information to the evaluator.
+func decodeConfig() *utils.Config {
+	return &utils.Config{
+		OutputMode: utils.OutputDecode,
+	}
+}
+
+// runProtocol runs the garbler and evaluator for the circuit and
+// returns their results.
+func runProtocol(t *testing.T, server pb.MpcSessionManagerServer,
+	cfg *utils.Config, circ *circuit.Circuit, sid string, g, e *big.Int) (
+	protocolResult, protocolResult) {
+	t.Helper()
+	return runParties(t, server, sid, cfg, circ, g, cfg, circ, e)
+}
+
+// runParties runs the garbler and evaluator with their own
+// configurations and circuits.
+func runParties(t *testing.T, server pb.MpcSessionManagerServer, sid string,
+	gcfg *utils.Config, gcirc *circuit.Circuit, g *big.Int,
+	ecfg *utils.Config, ecirc *circuit.Circuit, e *big.Int) (
+	protocolResult, protocolResult) {
+	t.Helper()
+
+	addr := startMessenger(t, server)
+
+	ch := make(chan protocolResult)
+	go func() {
+		conn, err := ot.NewConn(true, addr, sid)
+		if err != nil {
+			ch <- protocolResult{err: err}
+			return
+		}
+		defer conn.Close()
+		result, err := circuit.Garbler(gcfg, conn,
+			ot.NewCO(gcfg.GetRandom()), gcirc, g, false)
+		ch <- protocolResult{result: result, err: err}
+	}()
+
+	var eres protocolResult
+	conn, err := ot.NewConn(false, addr, sid)
+	if err != nil {
+		eres.err = err
+	} else {
+		eres.result, eres.err = circuit.Evaluator(ecfg, conn,
+			ot.NewCO(ecfg.GetRandom()), ecirc, e, false)
+		conn.Close()
+	}
+	gres := <-ch
+
+	return gres, eres
+}
+
+func TestProtocol(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	g := big.NewInt(0x11223344)
+	e := big.NewInt(0x55667788)
+	sum := uint32(g.Uint64()) + uint32(e.Uint64())
+	mul := uint32(g.Uint64()) * uint32(e.Uint64())
+
+	for _, garbling := range []utils.Garbling{
+		utils.HalfGates, utils.ThreeHalves, utils.Classic,
+	} {
+		for _, mode := range []utils.OutputMode{
+			utils.OutputDecode, utils.OutputGarbler,
+		} {
+			cfg := &utils.Config{
+				OutputMode: mode,
+				Garbling:   garbling,
+			}
+			gres, eres := runProtocol(t, ot.NewServer(), cfg, circ,
+				t.Name()+garbling.String()+mode.String(), g, e)
+			for _, r := range []protocolResult{gres, eres} {
+				if r.err != nil {
+					t.Fatalf("%v/%v: protocol failed: %v",
+						garbling, mode, r.err)
+				}
+				if len(r.result) != 2 {
+					t.Fatalf("%v/%v: unexpected # of results: %v",
+						garbling, mode, len(r.result))
+				}
+				if r.result[0].Uint64() != uint64(sum) {
+					t.Errorf("%v/%v: g+e: got %v, expected %v",
+						garbling, mode, r.result[0], sum)
+				}
+				if r.result[1].Uint64() != uint64(mul) {
+					t.Errorf("%v/%v: g*e: got %v, expected %v",
+						garbling, mode, r.result[1], mul)
+				}
+			}
+		}
+	}
+}
+
+// countingServer counts the bytes of the messages of the argument
+// topic.
+type countingServer struct {
+	*ot.MessengerServer
+	topic string
+	bytes atomic.Int64
+}
+
+func (s *countingServer) Inbox(ctx context.Context, req *pb.VecMessage) (
+	*pb.Void, error) {
+
+	for _, msg := range req.Values {
+		if msg.Topic == s.topic {
+			s.bytes.Add(int64(len(msg.Val)))
+		}
+	}
+	return s.MessengerServer.Inbox(ctx, req)
+}
+
+func TestProtocolGarbledSize(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	sizes := make(map[utils.Garbling]int64)
+	for _, garbling := range []utils.Garbling{
+		utils.HalfGates, utils.ThreeHalves, utils.Classic,
+	} {
+		server := &countingServer{
+			MessengerServer: ot.NewServer(),
+			topic:           "garbled gates",
+		}
+		cfg := &utils.Config{
+			Garbling: garbling,
+		}
+		gres, eres := runProtocol(t, server, cfg, circ,
+			t.Name()+garbling.String(), big.NewInt(3), big.NewInt(5))
+		for _, r := range []protocolResult{gres, eres} {
+			if r.err != nil {
+				t.Fatalf("%v: protocol failed: %v", garbling, r.err)
+			}
+		}
+		sizes[garbling] = server.bytes.Load()
+	}
+
+	// The three-halves tables take 25 bytes and the half-gates tables
+	// 32 bytes per AND gate.
+	ratio := float64(sizes[utils.ThreeHalves]) /
+		float64(sizes[utils.HalfGates])
+	if ratio < 0.75 || ratio > 0.80 {
+		t.Errorf("three-halves/half-gates size ratio %.3f (%d/%d bytes)",
+			ratio, sizes[utils.ThreeHalves], sizes[utils.HalfGates])
+	}
+	ratio = float64(sizes[utils.Classic]) / float64(sizes[utils.HalfGates])
+	if ratio < 1.95 || ratio > 2.05 {
+		t.Errorf("classic/half-gates size ratio %.3f (%d/%d bytes)",
+			ratio, sizes[utils.Classic], sizes[utils.HalfGates])
+	}
+}
+
+// isMismatch tests if the error is the target mismatch error or the
+// peer's mismatch abort. The peer that detects the mismatch first
+// aborts the protocol.
+func isMismatch(err, target error) bool {
+	var abort *ot.AbortError
+	if errors.As(err, &abort) {
+		return abort.Code == ot.AbortMismatch
+	}
+	return errors.Is(err, target)
+}
+
+// tamperingServer modifies the messages of the argument topic.
+type tamperingServer struct {
+	*ot.MessengerServer
+	topic string
+}
+
+func (s *tamperingServer) Inbox(ctx context.Context, req *pb.VecMessage) (
+	*pb.Void, error) {
+
+	for _, msg := range req.Values {
+		if msg.Topic == s.topic && len(msg.Val) > 0 {
+			msg.Val[len(msg.Val)-1] ^= 0x01
+		}
+	}
+	return s.MessengerServer.Inbox(ctx, req)
+}
+
+// echoingServer replaces the evaluator's messages of the topics with
+// the argument prefix with the garbler's messages of the same topics.
+type echoingServer struct {
+	*ot.MessengerServer
+	prefix string
+	mu     sync.Mutex
+	sent   map[string][]byte
+}
+
+func (s *echoingServer) Inbox(ctx context.Context, req *pb.VecMessage) (
+	*pb.Void, error) {
+
+	s.mu.Lock()
+	if s.sent == nil {
+		s.sent = make(map[string][]byte)
+	}
+	for _, msg := range req.Values {
+		if !strings.HasPrefix(msg.Topic, s.prefix) {
+			continue
+		}
+		val, ok := s.sent[msg.Topic]
+		if ok {
+			msg.Val = val
+		} else {
+			s.sent[msg.Topic] = bytes.Clone(msg.Val)
+		}
+	}
+	s.mu.Unlock()
+	return s.MessengerServer.Inbox(ctx, req)
+}
+
+func TestProtocolTranscriptMismatch(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	server := &tamperingServer{
+		MessengerServer: ot.NewServer(),
+		topic:           "result",
+	}
+	cfg := &utils.Config{
+		OutputMode: utils.OutputGarbler,
+	}
+	gres, eres := runProtocol(t, server, cfg, circ, t.Name(),
+		big.NewInt(1), big.NewInt(2))
+	for _, r := range []protocolResult{gres, eres} {
+		if !isMismatch(r.err, ot.ErrTranscriptMismatch) {
+			t.Errorf("expected transcript mismatch, got %v", r.err)
+		}
+		if r.result != nil {
+			t.Errorf("result returned on transcript mismatch")
+		}
+	}
+}
+
+const protocolPartiesCode = `
+package main
+
+// @output g garbler
+// @output 1 evaluator
+func main(a, b uint32) (g uint32, e uint32, both uint32) {
+    return a + b, a * b, a ^ b
+}
+`
+
+func TestProtocolOutputParties(t *testing.T) {
+	circ := compileProtocolTest(t, protocolPartiesCode)
+
+	expected := []circuit.Party{
+		circuit.PartyGarbler, circuit.PartyEvaluator, circuit.PartyBoth,
+	}
+	for idx, out := range circ.Outputs {
+		if out.Party != expected[idx] {
+			t.Fatalf("output %d: got party %v, expected %v",
+				idx, out.Party, expected[idx])
+		}
+	}
+
+	g := big.NewInt(7)
+	e := big.NewInt(5)
+	gres, eres := runProtocol(t, ot.NewServer(), decodeConfig(), circ,
+		t.Name(), g, e)
+	if gres.err != nil || eres.err != nil {
+		t.Fatalf("protocol failed: %v, %v", gres.err, eres.err)
+	}
+	if gres.result[0].Int64() != 12 || gres.result[1] != nil ||
+		gres.result[2].Int64() != 2 {
+		t.Errorf("garbler: unexpected result %v", gres.result)
+	}
+	if eres.result[0] != nil || eres.result[1].Int64() != 35 ||
+		eres.result[2].Int64() != 2 {
+		t.Errorf("evaluator: unexpected result %v", eres.result)
+	}
+
+	cfg := &utils.Config{
+		OutputMode: utils.OutputGarbler,
+	}
+	_, err := circuit.Evaluator(cfg, nil, nil, circ, e, false)
+	if err == nil {
+		t.Errorf("evaluator-only output accepted in %v mode",
+			cfg.OutputMode)
+	}
+}
+
+const protocolSharesCode = `
+package main
+
+// @share x xor
+// @share s add 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141
+// @share 2 add 4294967291
+func main(a, b uint256) (x uint256, s uint256, m uint32, p uint32) {
+    return a ^ b, a + b, uint32(a) % 4294967291, uint32(b)
+}
+`
+
+func TestProtocolShares(t *testing.T) {
+	circ := compileProtocolTest(t, protocolSharesCode)
+
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	p := big.NewInt(4294967291)
+
+	g, _ := new(big.Int).SetString("7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0", 16)
+	e := big.NewInt(0xfffffffe)
+
+	gres, eres := runProtocol(t, ot.NewServer(), decodeConfig(), circ,
+		t.Name(), g, e)
+	if gres.err != nil || eres.err != nil {
+		t.Fatalf("protocol failed: %v, %v", gres.err, eres.err)
+	}
+
+	x := new(big.Int).Xor(gres.result[0], eres.result[0])
+	if x.Cmp(new(big.Int).Xor(g, e)) != 0 {
+		t.Errorf("xor shares: got %x, expected %x", x, new(big.Int).Xor(g, e))
+	}
+
+	s := new(big.Int).Add(gres.result[1], eres.result[1])
+	s.Mod(s, n)
+	if s.Cmp(new(big.Int).Add(g, e)) != 0 {
+		t.Errorf("additive shares: got %x, expected %x",
+			s, new(big.Int).Add(g, e))
+	}
+	if gres.result[1].Cmp(n) >= 0 || eres.result[1].Cmp(n) >= 0 {
+		t.Errorf("additive shares not reduced: %x, %x",
+			gres.result[1], eres.result[1])
+	}
+
+	m := new(big.Int).Add(gres.result[2], eres.result[2])
+	m.Mod(m, p)
+	expected := new(big.Int).SetUint64(uint64(uint32(g.Uint64())))
+	expected.Mod(expected, p)
+	if m.Cmp(expected) != 0 {
+		t.Errorf("additive shares mod %v: got %v, expected %v", p, m, expected)
+	}
+
+	for _, r := range []protocolResult{gres, eres} {
+		if r.result[3].Cmp(e) != 0 {
+			t.Errorf("plain output: got %v, expected %v", r.result[3], e)
+		}
+	}
+}
+
+func TestExchangePublicInputs(t *testing.T) {
+	addr := startMessenger(t, ot.NewServer())
+
+	exchange := func(sid string, g, e map[string]*big.Int) (error, error) {
+		ch := make(chan error)
+		go func() {
+			conn, err := ot.NewConn(true, addr, sid)
+			if err != nil {
+				ch <- err
+				return
+			}
+			defer conn.Close()
+			ch <- circuit.ExchangePublicInputs(conn, g)
+		}()
+		conn, err := ot.NewConn(false, addr, sid)
+		if err == nil {
+			err = circuit.ExchangePublicInputs(conn, e)
+			conn.Close()
+		}
+		return <-ch, err
+	}
+
+	inputs := map[string]*big.Int{
+		"idx": big.NewInt(1),
+		"n":   big.NewInt(1000003),
+	}
+	gerr, eerr := exchange(t.Name()+"match", inputs, inputs)
+	if gerr != nil || eerr != nil {
+		t.Fatalf("exchange failed: %v, %v", gerr, eerr)
+	}
+
+	other := map[string]*big.Int{
+		"idx": big.NewInt(2),
+		"n":   big.NewInt(1000003),
+	}
+	gerr, eerr = exchange(t.Name()+"mismatch", inputs, other)
+	for _, err := range []error{gerr, eerr} {
+		if !errors.Is(err, circuit.ErrPublicInputMismatch) {
+			t.Errorf("expected public input mismatch, got %v", err)
+		}
+	}
+}
+
+func TestProtocolPublicInputs(t *testing.T) {
+	compile := func(n int64) *circuit.Circuit {
+		params := utils.NewParams()
+		params.OptPruneGates = true
+		params.PublicInputs = map[string]*big.Int{
+			"n": big.NewInt(n),
+		}
+		circ, _, err := compiler.New(params).Compile(`
+package main
+
+// @public n
+func main(g uint32, n uint32, e uint32) uint32 {
+    return (g + e) % n
+}
+`, nil)
+		if err != nil {
+			t.Fatalf("compile failed: %v", err)
+		}
+		return circ
+	}
+	circ := compile(1000)
+
+	cfg := new(utils.Config)
+	gres, eres := runProtocol(t, ot.NewServer(), cfg, circ, t.Name()+"match",
+		big.NewInt(700), big.NewInt(500))
+	for _, r := range []protocolResult{gres, eres} {
+		if r.err != nil {
+			t.Fatalf("protocol failed: %v", r.err)
+		}
+		if len(r.result) != 1 || r.result[0].Int64() != 200 {
+			t.Errorf("got %v, expected [200]", r.result)
+		}
+	}
+
+	gres, eres = runParties(t, ot.NewServer(), t.Name()+"mismatch",
+		cfg, circ, big.NewInt(700), cfg, compile(999), big.NewInt(500))
+	for _, r := range []protocolResult{gres, eres} {
+		if !errors.Is(r.err, circuit.ErrPublicInputMismatch) {
+			t.Errorf("expected public input mismatch, got %v", r.err)
+		}
+	}
+}
+
+func TestProtocolHandshake(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+	other := compileProtocolTest(t, `
+package main
+
+func main(g, e uint32) (uint32, uint32) {
+    return g - e, g * e
+}
+`)
+	cfg := new(utils.Config)
+	gres, eres := runParties(t, ot.NewServer(), t.Name()+"circuit",
+		cfg, circ, big.NewInt(1), cfg, other, big.NewInt(2))
+	for _, r := range []protocolResult{gres, eres} {
+		if !isMismatch(r.err, circuit.ErrCircuitMismatch) {
+			t.Errorf("expected circuit mismatch, got %v", r.err)
+		}
+	}
+
+	gcfg := &utils.Config{
+		OutputMode: utils.OutputDecode,
+	}
+	gres, eres = runParties(t, ot.NewServer(), t.Name()+"mode",
+		gcfg, circ, big.NewInt(1), cfg, circ, big.NewInt(2))
+	for _, r := range []protocolResult{gres, eres} {
+		if !isMismatch(r.err, circuit.ErrProtocolMismatch) {
+			t.Errorf("expected protocol mismatch, got %v", r.err)
+		}
+	}
+
+	gcfg = &utils.Config{
+		Garbling: utils.ThreeHalves,
+	}
+	gres, eres = runParties(t, ot.NewServer(), t.Name()+"garbling",
+		gcfg, circ, big.NewInt(1), cfg, circ, big.NewInt(2))
+	for _, r := range []protocolResult{gres, eres} {
+		if !isMismatch(r.err, circuit.ErrProtocolMismatch) {
+			t.Errorf("expected protocol mismatch, got %v", r.err)
+		}
+	}
+
+	// The peers use the garbler's first scheme the evaluator accepts.
+	gcfg = &utils.Config{
+		AcceptGarbling: []utils.Garbling{
+			utils.ThreeHalves, utils.Classic, utils.HalfGates,
+		},
+	}
+	ecfg := &utils.Config{
+		AcceptGarbling: []utils.Garbling{utils.HalfGates, utils.Classic},
+	}
+	gres, eres = runParties(t, ot.NewServer(), t.Name()+"negotiate",
+		gcfg, circ, big.NewInt(1), ecfg, circ, big.NewInt(2))
+	for _, r := range []protocolResult{gres, eres} {
+		if r.err != nil {
+			t.Fatalf("negotiation failed: %v", r.err)
+		}
+		if r.result[0].Int64() != 3 || r.result[1].Int64() != 2 {
+			t.Errorf("unexpected result %v", r.result)
+		}
+	}
+}
+
+func TestProtocolAbort(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	// Tampered decoding fails the evaluator before the OT.
+	server := &tamperingServer{
+		MessengerServer: ot.NewServer(),
+		topic:           "output decoding",
+	}
+	start := time.Now()
+	gres, eres := runProtocol(t, server, decodeConfig(), circ, t.Name(),
+		big.NewInt(1), big.NewInt(2))
+	if eres.err == nil {
+		t.Fatalf("evaluator succeeded with tampered decoding")
+	}
+	var abort *ot.AbortError
+	if !errors.As(gres.err, &abort) {
+		t.Fatalf("expected peer abort, got %v", gres.err)
+	}
+	if len(abort.Reason) == 0 {
+		t.Errorf("abort without reason")
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("abort took %v", time.Since(start))
+	}
+}
+
+// failingServer fails the messages of the argument topic as if the
+// messenger was unavailable.
+type failingServer struct {
+	*ot.MessengerServer
+	topic string
+	fail  atomic.Bool
+}
+
+func (s *failingServer) Inbox(ctx context.Context, req *pb.VecMessage) (
+	*pb.Void, error) {
+
+	for _, msg := range req.Values {
+		if msg.Topic == s.topic && s.fail.Load() {
+			return nil, status.Error(codes.Unavailable, "messenger down")
+		}
+	}
+	return s.MessengerServer.Inbox(ctx, req)
+}
+
+func TestProtocolResume(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	key := make([]byte, 32)
+	gstore, err := circuit.NewFileCheckpoints(t.TempDir(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	estore, err := circuit.NewFileCheckpoints(t.TempDir(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcfg := &utils.Config{
+		Checkpoints: gstore,
+	}
+	ecfg := &utils.Config{
+		Checkpoints: estore,
+	}
+
+	server := &failingServer{
+		MessengerServer: ot.NewServer(),
+		topic:           "result labels",
+	}
+	server.fail.Store(true)
+	addr := startMessenger(t, server)
+	sid := t.Name()
+
+	g := big.NewInt(0x11223344)
+	e := big.NewInt(0x55667788)
+
+	ch := make(chan protocolResult)
+	go func() {
+		conn, err := ot.NewConn(true, addr, sid)
+		if err != nil {
+			ch <- protocolResult{err: err}
+			return
+		}
+		defer conn.Close()
+		result, err := circuit.Garbler(gcfg, conn, ot.NewCO(nil), circ, g,
+			false)
+		ch <- protocolResult{result: result, err: err}
+	}()
+
+	evaluate := func() ([]*big.Int, error) {
+		conn, err := ot.NewConn(false, addr, sid)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return circuit.Evaluator(ecfg, conn, ot.NewCO(nil), circ, e, false)
+	}
+
+	// The evaluator fails after the OT.
+	if _, err := evaluate(); err == nil {
+		t.Fatalf("evaluator succeeded with failing messenger")
+	}
+	data, err := estore.Load(sid + "/evaluator")
+	if err != nil || data == nil {
+		t.Fatalf("evaluator checkpoint not saved: %v", err)
+	}
+
+	// The restarted evaluator resumes the session.
+	server.fail.Store(false)
+	eresult, err := evaluate()
+	if err != nil {
+		t.Fatalf("resumed evaluator failed: %v", err)
+	}
+	gres := <-ch
+	if gres.err != nil {
+		t.Fatalf("garbler failed: %v", gres.err)
+	}
+
+	sum := uint64(uint32(g.Uint64() + e.Uint64()))
+	for _, result := range [][]*big.Int{gres.result, eresult} {
+		if len(result) != 2 || result[0].Uint64() != sum {
+			t.Errorf("invalid result %v, expected sum %v", result, sum)
+		}
+	}
+	for _, store := range []*circuit.FileCheckpoints{gstore, estore} {
+		for _, id := range []string{sid + "/garbler", sid + "/evaluator"} {
+			data, err := store.Load(id)
+			if err != nil || data != nil {
+				t.Errorf("checkpoint %s not removed: %v", id, err)
+			}
+		}
+	}
+}
+
+func TestProtocolReplay(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	addr := startMessenger(t, ot.NewServer())
+	sid := t.Name()
+
+	g := big.NewInt(0x11223344)
+	e := big.NewInt(0x55667788)
+
+	// The parties run with random session DRBGs of different
+	// algorithms. The recordings hold the DRBGs.
+	algs := map[bool]drbg.Algorithm{
+		true:  drbg.ChaCha20,
+		false: drbg.AESCTR,
+	}
+	run := func(garbler bool, rec *bytes.Buffer) ([]*big.Int, error) {
+		conn, err := ot.NewConn(garbler, addr, sid)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		if err := conn.Record(rec); err != nil {
+			return nil, err
+		}
+		cfg := &utils.Config{
+			DRBG: algs[garbler],
+		}
+		if garbler {
+			return circuit.Garbler(cfg, conn, ot.NewCO(nil), circ, g, false)
+		}
+		return circuit.Evaluator(cfg, conn, ot.NewCO(nil), circ, e, false)
+	}
+	replay := func(rec []byte, input *big.Int) ([]*big.Int, error) {
+		conn, hdr, err := ot.NewReplayConn(bytes.NewReader(rec))
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		if hdr.Session != sid {
+			t.Errorf("recording session %v, expected %v", hdr.Session, sid)
+		}
+		if hdr.DRBG != algs[hdr.Garbler] || len(hdr.Seed) != drbg.SeedSize {
+			t.Errorf("recording DRBG %v/%x, expected %v",
+				hdr.DRBG, hdr.Seed, algs[hdr.Garbler])
+		}
+		cfg := new(utils.Config)
+		if hdr.Garbler {
+			return circuit.Garbler(cfg, conn, ot.NewCO(nil), circ, input,
+				false)
+		}
+		return circuit.Evaluator(cfg, conn, ot.NewCO(nil), circ, input,
+			false)
+	}
+
+	var grec, erec bytes.Buffer
+	ch := make(chan protocolResult)
+	go func() {
+		result, err := run(true, &grec)
+		ch <- protocolResult{result: result, err: err}
+	}()
+	eresult, err := run(false, &erec)
+	if err != nil {
+		t.Fatalf("evaluator failed: %v", err)
+	}
+	gres := <-ch
+	if gres.err != nil {
+		t.Fatalf("garbler failed: %v", gres.err)
+	}
+
+	for _, tc := range []struct {
+		rec    []byte
+		input  *big.Int
+		result []*big.Int
+	}{
+		{grec.Bytes(), g, gres.result},
+		{erec.Bytes(), e, eresult},
+	} {
+		result, err := replay(tc.rec, tc.input)
+		if err != nil {
+			t.Fatalf("replay failed: %v", err)
+		}
+		if len(result) != len(tc.result) {
+			t.Fatalf("replay returned %v, expected %v", result, tc.result)
+		}
+		for i := range result {
+			if result[i].Cmp(tc.result[i]) != 0 {
+				t.Errorf("replay returned %v, expected %v", result, tc.result)
+			}
+		}
+
+		// Replay with a different input diverges from the recording.
+		_, err = replay(tc.rec, new(big.Int).Add(tc.input, big.NewInt(1)))
+		if !errors.Is(err, ot.ErrReplayMismatch) {
+			t.Errorf("expected replay mismatch, got %v", err)
+		}
+	}
+}
+
+// runBatch runs the garbler and evaluator for the batches of input
+// sets.
+func runBatch(t *testing.T, cfg *utils.Config, circ *circuit.Circuit,
+	sid string, g, e []*big.Int) ([][]*big.Int, [][]*big.Int, error, error) {
+	t.Helper()
+
+	addr := startMessenger(t, ot.NewServer())
+
+	type batchResult struct {
+		result [][]*big.Int
+		err    error
+	}
+	ch := make(chan batchResult)
+	go func() {
+		conn, err := ot.NewConn(true, addr, sid)
+		if err != nil {
+			ch <- batchResult{err: err}
+			return
+		}
+		defer conn.Close()
+		result, err := circuit.GarblerBatch(cfg, conn, ot.NewCO(nil), circ,
+			g, false)
+		ch <- batchResult{result: result, err: err}
+	}()
+
+	var eresult [][]*big.Int
+	conn, err := ot.NewConn(false, addr, sid)
+	if err == nil {
+		eresult, err = circuit.EvaluatorBatch(cfg, conn, ot.NewCO(nil), circ,
+			e, false)
+		conn.Close()
+	}
+	gres := <-ch
+
+	return gres.result, eresult, gres.err, err
+}
+
+func TestProtocolBatch(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	var g, e []*big.Int
+	for i := 0; i < 5; i++ {
+		g = append(g, big.NewInt(int64(0x11223344*(i+1))))
+		e = append(e, big.NewInt(int64(0x01020304+i)))
+	}
+	for _, mode := range []utils.OutputMode{
+		utils.OutputDecode, utils.OutputGarbler,
+	} {
+		cfg := &utils.Config{
+			OutputMode: mode,
+			Workers:    4,
+		}
+		gresult, eresult, gerr, eerr := runBatch(t, cfg, circ,
+			t.Name()+mode.String(), g, e)
+		if gerr != nil || eerr != nil {
+			t.Fatalf("%v: batch failed: garbler=%v, evaluator=%v",
+				mode, gerr, eerr)
+		}
+		for _, results := range [][][]*big.Int{gresult, eresult} {
+			if len(results) != len(g) {
+				t.Fatalf("%v: got %d results, expected %d",
+					mode, len(results), len(g))
+			}
+			for i, result := range results {
+				sum := uint32(g[i].Uint64()) + uint32(e[i].Uint64())
+				mul := uint32(g[i].Uint64()) * uint32(e[i].Uint64())
+				if result[0].Uint64() != uint64(sum) ||
+					result[1].Uint64() != uint64(mul) {
+					t.Errorf("%v: instance %d: got %v, expected [%v %v]",
+						mode, i, result, sum, mul)
+				}
+			}
+		}
+	}
+
+	// The peers must run the same number of instances.
+	_, _, gerr, eerr := runBatch(t, new(utils.Config), circ,
+		t.Name()+"mismatch", g, e[:3])
+	for _, err := range []error{gerr, eerr} {
+		if !isMismatch(err, circuit.ErrProtocolMismatch) {
+			t.Errorf("expected protocol mismatch, got %v", err)
+		}
+	}
+}
+
+func TestProtocolOffline(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+	addr := startMessenger(t, ot.NewServer())
+	cfg := new(utils.Config)
+
+	ginv := circuit.NewInventory()
+	einv := circuit.NewInventory()
+
+	// run runs the garbler and evaluator functions in the session.
+	run := func(sid string,
+		g func(conn *ot.Conn) ([]*big.Int, []string, error),
+		e func(conn *ot.Conn) ([]*big.Int, []string, error)) (
+		[]*big.Int, []*big.Int, []string, error, error) {
+
+		type result struct {
+			result []*big.Int
+			ids    []string
+			err    error
+		}
+		ch := make(chan result)
+		go func() {
+			conn, err := ot.NewConn(true, addr, sid)
+			if err != nil {
+				ch <- result{err: err}
+				return
+			}
+			defer conn.Close()
+			r, ids, err := g(conn)
+			ch <- result{result: r, ids: ids, err: err}
+		}()
+		conn, err := ot.NewConn(false, addr, sid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		eresult, eids, eerr := e(conn)
+		gres := <-ch
+		if eerr == nil && gres.err == nil && !slices.Equal(gres.ids, eids) {
+			t.Errorf("instance IDs differ: %v != %v", gres.ids, eids)
+		}
+		return gres.result, eresult, eids, gres.err, eerr
+	}
+
+	// Offline phase.
+	_, _, ids, gerr, eerr := run(t.Name()+"offline",
+		func(conn *ot.Conn) ([]*big.Int, []string, error) {
+			ids, err := circuit.GarblerOffline(cfg, conn, circ, ginv, 3, false)
+			return nil, ids, err
+		},
+		func(conn *ot.Conn) ([]*big.Int, []string, error) {
+			ids, err := circuit.EvaluatorOffline(cfg, conn, circ, einv, 3,
+				false)
+			return nil, ids, err
+		})
+	if gerr != nil || eerr != nil {
+		t.Fatalf("offline phase failed: garbler=%v, evaluator=%v", gerr, eerr)
+	}
+	if len(ids) != 3 || ginv.Len() != 3 || einv.Len() != 3 {
+		t.Fatalf("invalid inventories: ids=%v, garbler=%v, evaluator=%v",
+			ids, ginv.IDs(), einv.IDs())
+	}
+
+	// Online phases.
+	online := func(sid, id string, g, e *big.Int) (
+		[]*big.Int, []*big.Int, error, error) {
+		gresult, eresult, _, gerr, eerr := run(sid,
+			func(conn *ot.Conn) ([]*big.Int, []string, error) {
+				r, err := circuit.GarblerOnline(cfg, conn, ot.NewCO(nil), circ,
+					ginv, id, g, false)
+				return r, nil, err
+			},
+			func(conn *ot.Conn) ([]*big.Int, []string, error) {
+				r, err := circuit.EvaluatorOnline(cfg, conn, ot.NewCO(nil),
+					circ, einv, id, e, false)
+				return r, nil, err
+			})
+		return gresult, eresult, gerr, eerr
+	}
+	for i, id := range ids {
+		g := big.NewInt(int64(1000 + i))
+		e := big.NewInt(int64(7 * i))
+		gresult, eresult, gerr, eerr := online(t.Name()+id, id, g, e)
+		if gerr != nil || eerr != nil {
+			t.Fatalf("online phase %s failed: garbler=%v, evaluator=%v",
+				id, gerr, eerr)
+		}
+		for _, result := range [][]*big.Int{gresult, eresult} {
+			if result[0].Int64() != g.Int64()+e.Int64() ||
+				result[1].Int64() != g.Int64()*e.Int64() {
+				t.Errorf("online phase %s: got %v", id, result)
+			}
+		}
+	}
+	if ginv.Len() != 0 || einv.Len() != 0 {
+		t.Errorf("instances not consumed: garbler=%v, evaluator=%v",
+			ginv.IDs(), einv.IDs())
+	}
+
+	// The instances are used only once.
+	_, _, gerr, eerr = online(t.Name()+"reuse", ids[0], big.NewInt(1),
+		big.NewInt(2))
+	for _, err := range []error{gerr, eerr} {
+		if !errors.Is(err, circuit.ErrUnknownInstance) {
+			t.Errorf("expected unknown instance, got %v", err)
+		}
+	}
+}
+
+const protocolDualCode = `
+package main
+
+func main(a, b uint32) (uint32, bool) {
+    return a - b, a < b
+}
+`
+
+// runDual runs the dual-execution protocol for the circuit and returns
+// the results of the parties.
+func runDual(t *testing.T, server pb.MpcSessionManagerServer,
+	cfg *utils.Config, circ *circuit.Circuit, sid string, a, b *big.Int) (
+	protocolResult, protocolResult) {
+	t.Helper()
+
+	addr := startMessenger(t, server)
+
+	ch := make(chan protocolResult)
+	go func() {
+		conn, err := ot.NewConn(true, addr, sid)
+		if err != nil {
+			ch <- protocolResult{err: err}
+			return
+		}
+		defer conn.Close()
+		result, err := circuit.DualExecution(cfg, conn,
+			ot.NewCO(cfg.GetRandom()), circ, circuit.PartyGarbler, a, false)
+		ch <- protocolResult{result: result, err: err}
+	}()
+
+	var bres protocolResult
+	conn, err := ot.NewConn(false, addr, sid)
+	if err != nil {
+		bres.err = err
+	} else {
+		bres.result, bres.err = circuit.DualExecution(cfg, conn,
+			ot.NewCO(cfg.GetRandom()), circ, circuit.PartyEvaluator, b, false)
+		conn.Close()
+	}
+	ares := <-ch
+
+	return ares, bres
+}
+
+func TestProtocolDual(t *testing.T) {
+	circ := compileProtocolTest(t, protocolDualCode)
+
+	a := big.NewInt(1000)
+	b := big.NewInt(1234)
+	diff := uint32(a.Uint64()) - uint32(b.Uint64())
+
+	for _, garbling := range []utils.Garbling{
+		utils.HalfGates, utils.ThreeHalves, utils.Classic,
+	} {
+		cfg := &utils.Config{
+			Garbling:   garbling,
+			Workers:    2,
+			OutputMode: utils.OutputDecode,
+		}
+		ares, bres := runDual(t, ot.NewServer(), cfg, circ,
+			t.Name()+garbling.String(), a, b)
+		for _, r := range []protocolResult{ares, bres} {
+			if r.err != nil {
+				t.Fatalf("%v: dual execution failed: %v", garbling, r.err)
+			}
+			if len(r.result) != 2 || r.result[0].Uint64() != uint64(diff) ||
+				r.result[1].Uint64() != 1 {
+				t.Errorf("%v: got %v, expected [%v 1]",
+					garbling, r.result, diff)
+			}
+		}
+	}
+
+	// The results are not released if the equality test fails.
+	server := &tamperingServer{
+		MessengerServer: ot.NewServer(),
+		topic:           "equality commit",
+	}
+	ares, bres := runDual(t, server, decodeConfig(), circ,
+		t.Name()+"mismatch", a, b)
+	for _, r := range []protocolResult{ares, bres} {
+		if !isMismatch(r.err, circuit.ErrOutputMismatch) {
+			t.Errorf("expected output mismatch, got %v", r.err)
+		}
+		if r.result != nil {
+			t.Errorf("result returned on output mismatch")
+		}
+	}
+
+	// The evaluator can't pass the garbler's equality test by echoing
+	// the garbler's messages back.
+	echo := &echoingServer{
+		MessengerServer: ot.NewServer(),
+		prefix:          "equality",
+	}
+	ares, bres = runDual(t, echo, decodeConfig(), circ,
+		t.Name()+"echo", a, b)
+	if !errors.Is(ares.err, circuit.ErrOutputMismatch) {
+		t.Errorf("expected output mismatch, got %v", ares.err)
+	}
+	for _, r := range []protocolResult{ares, bres} {
+		if r.result != nil {
+			t.Errorf("result returned on echoed equality test")
+		}
+	}
+
+	// The dual execution reveals the outputs to both parties.
+	cfg := &utils.Config{
+		OutputMode: utils.OutputGarbler,
+	}
+	ares, bres = runDual(t, ot.NewServer(), cfg, circ, t.Name()+"mode", a, b)
+	for _, r := range []protocolResult{ares, bres} {
+		if r.err == nil {
+			t.Errorf("dual execution accepted output mode %v",
+				cfg.OutputMode)
+		}
+	}
+}
+
+// runCutAndChoose runs the cut-and-choose garbler and evaluator for
+// the circuit and returns their results.
+func runCutAndChoose(t *testing.T, server pb.MpcSessionManagerServer,
+	cfg *utils.Config, circ *circuit.Circuit, sid string, instances int,
+	g, e *big.Int) (protocolResult, protocolResult) {
+	t.Helper()
+
+	addr := startMessenger(t, server)
+
+	ch := make(chan protocolResult)
+	go func() {
+		conn, err := ot.NewConn(true, addr, sid)
+		if err != nil {
+			ch <- protocolResult{err: err}
+			return
+		}
+		defer conn.Close()
+		result, err := circuit.GarblerCutAndChoose(cfg, conn,
+			ot.NewCO(cfg.GetRandom()), circ, g, instances, false)
+		ch <- protocolResult{result: result, err: err}
+	}()
+
+	var eres protocolResult
+	conn, err := ot.NewConn(false, addr, sid)
+	if err != nil {
+		eres.err = err
+	} else {
+		eres.result, eres.err = circuit.EvaluatorCutAndChoose(cfg, conn,
+			ot.NewCO(cfg.GetRandom()), circ, e, instances, false)
+		conn.Close()
+	}
+	gres := <-ch
+
+	return gres, eres
+}
+
+func TestProtocolCutAndChoose(t *testing.T) {
+	circ := compileProtocolTest(t, protocolDualCode)
+
+	g := big.NewInt(4321)
+	e := big.NewInt(1234)
+	diff := uint32(g.Uint64()) - uint32(e.Uint64())
+
+	for _, garbling := range []utils.Garbling{
+		utils.HalfGates, utils.ThreeHalves, utils.Classic,
+	} {
+		cfg := &utils.Config{
+			Garbling:   garbling,
+			OutputMode: utils.OutputDecode,
+		}
+		gres, eres := runCutAndChoose(t, ot.NewServer(), cfg, circ,
+			t.Name()+garbling.String(), 4, g, e)
+		for _, r := range []protocolResult{gres, eres} {
+			if r.err != nil {
+				t.Fatalf("%v: cut-and-choose failed: %v", garbling, r.err)
+			}
+			if len(r.result) != 2 || r.result[0].Uint64() != uint64(diff) ||
+				r.result[1].Uint64() != 0 {
+				t.Errorf("%v: got %v, expected [%v 0]",
+					garbling, r.result, diff)
+			}
+		}
+	}
+
+	// The evaluator detects the modified commitments of the check
+	// and evaluation instances.
+	server := &tamperingServer{
+		MessengerServer: ot.NewServer(),
+		topic:           "hash decoding",
+	}
+	gres, eres := runCutAndChoose(t, server, decodeConfig(), circ,
+		t.Name()+"tampered", 4, g, e)
+	if !errors.Is(eres.err, circuit.ErrCheatingDetected) {
+		t.Errorf("expected cheating detected, got %v", eres.err)
+	}
+	var abort *ot.AbortError
+	if !errors.As(gres.err, &abort) || abort.Code != ot.AbortEvaluation {
+		t.Errorf("expected evaluation abort, got %v", gres.err)
+	}
+	for _, r := range []protocolResult{gres, eres} {
+		if r.result != nil {
+			t.Errorf("result returned on cheating")
+		}
+	}
+}
+
+func TestProtocolAuthGarbling(t *testing.T) {
+	cfg := &utils.Config{
+		Protocol:   utils.AuthGarbling,
+		OutputMode: utils.OutputDecode,
+	}
+
+	circ := compileProtocolTest(t, protocolTestCode)
+	g := big.NewInt(4321)
+	e := big.NewInt(1234)
+	gres, eres := runProtocol(t, ot.NewServer(), cfg, circ, t.Name(), g, e)
+	for _, r := range []protocolResult{gres, eres} {
+		if r.err != nil {
+			t.Fatalf("authenticated garbling failed: %v", r.err)
+		}
+		if len(r.result) != 2 || r.result[0].Int64() != 5555 ||
+			r.result[1].Int64() != 4321*1234 {
+			t.Errorf("got %v, expected [5555 %v]", r.result, 4321*1234)
+		}
+	}
+
+	circ = compileProtocolTest(t, protocolPartiesCode)
+	gres, eres = runProtocol(t, ot.NewServer(), cfg, circ, t.Name()+"parties",
+		big.NewInt(7), big.NewInt(5))
+	if gres.err != nil || eres.err != nil {
+		t.Fatalf("authenticated garbling failed: %v, %v", gres.err, eres.err)
+	}
+	if gres.result[0].Int64() != 12 || gres.result[1] != nil ||
+		gres.result[2].Int64() != 2 {
+		t.Errorf("garbler: unexpected result %v", gres.result)
+	}
+	if eres.result[0] != nil || eres.result[1].Int64() != 35 ||
+		eres.result[2].Int64() != 2 {
+		t.Errorf("evaluator: unexpected result %v", eres.result)
+	}
+
+	// Both parties must select the protocol.
+	gres, eres = runParties(t, ot.NewServer(), t.Name()+"mismatch",
+		cfg, circ, g, decodeConfig(), circ, e)
+	for _, r := range []protocolResult{gres, eres} {
+		if !isMismatch(r.err, circuit.ErrProtocolMismatch) {
+			t.Errorf("expected protocol mismatch, got %v", r.err)
+		}
+	}
+
+	// The parties detect the modified AND triple checks.
+	server := &tamperingServer{
+		MessengerServer: ot.NewServer(),
+		topic:           "and digest",
+	}
+	gres, eres = runProtocol(t, server, cfg, circ, t.Name()+"tampered", g, e)
+	for _, r := range []protocolResult{gres, eres} {
+		var abort *ot.AbortError
+		if !errors.Is(r.err, circuit.ErrAuthenticationFailed) &&
+			(!errors.As(r.err, &abort) || abort.Code != ot.AbortEvaluation) {
+			t.Errorf("expected authentication failure, got %v", r.err)
+		}
+		if r.result != nil {
+			t.Errorf("result returned on authentication failure")
+		}
+	}
+}
@@ -0,0 +1,69 @@
+//
+// public.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// ErrPublicInputMismatch is returned when the peers have different
+// public inputs.
+var ErrPublicInputMismatch = errors.New("public input mismatch")
+
+// ExchangePublicInputs sends the public input values to the peer and
+// verifies that the peer uses the same values. The protocol handshake
+// verifies the public inputs of the compiled circuits. This function
+// lets the parties agree on the values before compiling the circuit.
+func ExchangePublicInputs(conn *ot.Conn, inputs map[string]*big.Int) error {
+	if err := conn.DirectSend(inputs, "public inputs"); err != nil {
+		return errors.Wrap(err,
+			"in mpc_hd::ExchangePublicInputs(...), when sending public inputs.")
+	}
+	var peer map[string]*big.Int
+	if err := conn.DirectRecv(&peer, "public inputs"); err != nil {
+		return errors.Wrap(err,
+			"in mpc_hd::ExchangePublicInputs(...), when receiving public inputs.")
+	}
+	return comparePublicInputs(inputs, peer)
+}
+
+func comparePublicInputs(ours, theirs map[string]*big.Int) error {
+	var names []string
+	for name := range ours {
+		names = append(names, name)
+	}
+	for name := range theirs {
+		if _, ok := ours[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		a, ok := ours[name]
+		if !ok {
+			return errors.Wrapf(ErrPublicInputMismatch,
+				"%s set only by peer", name)
+		}
+		b, ok := theirs[name]
+		if !ok {
+			return errors.Wrapf(ErrPublicInputMismatch,
+				"%s not set by peer", name)
+		}
+		if a == nil || b == nil || a.Cmp(b) != 0 {
+			return errors.Wrapf(ErrPublicInputMismatch,
+				"%s: %v != %v", name, a, b)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+//
+// shares.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/markkurossi/mpc/compiler/types"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// Share specifies how an output is secret-shared between the garbler
+// and evaluator.
+type Share byte
+
+// Output sharing modes.
+const (
+	// ShareNone reveals the output value to its parties.
+	ShareNone Share = iota
+
+	// ShareXOR gives both parties a random share so that the output
+	// value is the XOR of the shares.
+	ShareXOR
+
+	// ShareAdditive gives both parties a random share so that the
+	// output value is the sum of the shares modulo the public
+	// modulus of the output. The output value must be less than the
+	// modulus.
+	ShareAdditive
+)
+
+var shareNames = map[Share]string{
+	ShareNone:     "none",
+	ShareXOR:      "xor",
+	ShareAdditive: "add",
+}
+
+func (s Share) String() string {
+	name, ok := shareNames[s]
+	if ok {
+		return name
+	}
+	return fmt.Sprintf("{Share %d}", s)
+}
+
+// ParseShare parses the share name.
+func ParseShare(name string) (Share, error) {
+	for s, n := range shareNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return ShareNone, fmt.Errorf("invalid share: %s", name)
+}
+
+// checkShare verifies the share attributes of the output argument.
+func (io IOArg) checkShare() error {
+	switch io.Share {
+	case ShareNone, ShareXOR:
+		if io.Modulus != nil {
+			return fmt.Errorf("output %v: modulus without additive share",
+				io)
+		}
+	case ShareAdditive:
+		if io.Modulus == nil || io.Modulus.Cmp(big.NewInt(2)) < 0 {
+			return fmt.Errorf("output %v: invalid modulus %v", io, io.Modulus)
+		}
+		if io.Modulus.BitLen() > int(io.Type.Bits) {
+			return fmt.Errorf("output %v: modulus %v does not fit in %d bits",
+				io, io.Modulus, io.Type.Bits)
+		}
+	default:
+		return fmt.Errorf("output %v: invalid share %v", io, io.Share)
+	}
+	if io.Share != ShareNone && io.Party != PartyBoth {
+		return fmt.Errorf("output %v: shared output assigned to %v",
+			io, io.Party)
+	}
+	return nil
+}
+
+// hasAdditiveShares tests if any of the circuit outputs is shared
+// additively.
+func (c *Circuit) hasAdditiveShares() bool {
+	for _, out := range c.Outputs {
+		if out.Share == ShareAdditive {
+			return true
+		}
+	}
+	return false
+}
+
+// shareBuilder appends gates to a circuit.
+type shareBuilder struct {
+	gates []Gate
+	next  Wire
+}
+
+func (b *shareBuilder) gate(op Operation, a, c Wire) Wire {
+	w := b.next
+	b.next++
+	b.gates = append(b.gates, Gate{
+		Input0: a,
+		Input1: c,
+		Output: w,
+		Op:     op,
+	})
+	return w
+}
+
+// add returns the sum x+y+cin and the carry out.
+func (b *shareBuilder) add(x, y []Wire, cin Wire) ([]Wire, Wire) {
+	sum := make([]Wire, len(x))
+	for i := 0; i < len(x); i++ {
+		axc := b.gate(XOR, x[i], cin)
+		bxc := b.gate(XOR, y[i], cin)
+		sum[i] = b.gate(XOR, x[i], bxc)
+		cin = b.gate(XOR, b.gate(AND, axc, bxc), cin)
+	}
+	return sum, cin
+}
+
+// expandShares returns a circuit that reveals the additively shared
+// outputs only to the evaluator, masked with the garbler's mask
+// inputs. The mask inputs are appended to the garbler's input and the
+// circuit computes (value + mask) mod modulus for each additively
+// shared output. The function returns the circuit unmodified if it
+// does not have additively shared outputs.
+func (c *Circuit) expandShares() (*Circuit, error) {
+	for _, out := range c.Outputs {
+		if err := out.checkShare(); err != nil {
+			return nil, err
+		}
+	}
+	if !c.hasAdditiveShares() {
+		return c, nil
+	}
+	if len(c.Inputs) != 2 {
+		return nil, fmt.Errorf("additive shares need 2 parties, got %d",
+			len(c.Inputs))
+	}
+
+	var k int
+	for _, out := range c.Outputs {
+		if out.Share == ShareAdditive {
+			k += int(out.Type.Bits)
+		}
+	}
+	n0 := Wire(c.Inputs[0].Type.Bits)
+	remap := func(w Wire) Wire {
+		if w >= n0 {
+			return w + Wire(k)
+		}
+		return w
+	}
+
+	b := &shareBuilder{
+		next: Wire(c.NumWires + k),
+	}
+	for _, g := range c.Gates {
+		g.Input0 = remap(g.Input0)
+		if g.Op != INV {
+			g.Input1 = remap(g.Input1)
+		}
+		g.Output = remap(g.Output)
+		g.Level = 0
+		b.gates = append(b.gates, g)
+	}
+
+	zero := b.gate(XOR, n0, n0)
+	one := b.gate(XNOR, n0, n0)
+
+	var outputs IO
+	var results []Wire
+
+	wire := Wire(c.NumWires - c.Outputs.Size())
+	mask := n0
+	for _, out := range c.Outputs {
+		bits := int(out.Type.Bits)
+		var value []Wire
+		for i := 0; i < bits; i++ {
+			value = append(value, remap(wire))
+			wire++
+		}
+		if out.Share != ShareAdditive {
+			outputs = append(outputs, out)
+			results = append(results, value...)
+			continue
+		}
+
+		// t = value + mask
+		x := append(value, zero)
+		var y []Wire
+		for i := 0; i < bits; i++ {
+			y = append(y, mask)
+			mask++
+		}
+		y = append(y, zero)
+		t, _ := b.add(x, y, zero)
+
+		// u = t - modulus, carry is set if t >= modulus.
+		var neg []Wire
+		for i := 0; i <= bits; i++ {
+			if out.Modulus.Bit(i) == 0 {
+				neg = append(neg, one)
+			} else {
+				neg = append(neg, zero)
+			}
+		}
+		u, ge := b.add(t, neg, one)
+
+		for i := 0; i < bits; i++ {
+			d := b.gate(XOR, t[i], u[i])
+			results = append(results,
+				b.gate(XOR, t[i], b.gate(AND, ge, d)))
+		}
+
+		out.Share = ShareNone
+		out.Modulus = nil
+		out.Party = PartyEvaluator
+		outputs = append(outputs, out)
+	}
+
+	// The outputs are the last wires of the circuit.
+	for _, w := range results {
+		b.gate(XOR, w, zero)
+	}
+
+	var stats Stats
+	for _, g := range b.gates {
+		stats[g.Op]++
+	}
+
+	inputs := make(IO, len(c.Inputs))
+	copy(inputs, c.Inputs)
+	inputs[0] = IOArg{
+		Name: c.Inputs[0].Name,
+		Type: types.Info{
+			Type:       types.TUint,
+			IsConcrete: true,
+			Bits:       c.Inputs[0].Type.Bits + types.Size(k),
+		},
+	}
+
+	return &Circuit{
+		NumGates: len(b.gates),
+		NumWires: int(b.next),
+		Inputs:   inputs,
+		Outputs:  outputs,
+		Gates:    b.gates,
+		Stats:    stats,
+	}, nil
+}
+
+// shareMasks creates random masks for the additively shared outputs.
+// The function returns the masks as garbler's mask input and the
+// garbler's output shares.
+func (c *Circuit) shareMasks(r io.Reader) (*big.Int, []*big.Int, error) {
+	input := new(big.Int)
+	shares := make([]*big.Int, len(c.Outputs))
+
+	var bit int
+	for idx, out := range c.Outputs {
+		if out.Share != ShareAdditive {
+			continue
+		}
+		m, err := rand.Int(r, out.Modulus)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := 0; i < int(out.Type.Bits); i++ {
+			input.SetBit(input, bit, m.Bit(i))
+			bit++
+		}
+		shares[idx] = new(big.Int).Sub(out.Modulus, m)
+		shares[idx].Mod(shares[idx], out.Modulus)
+	}
+	return input, shares, nil
+}
+
+// xorShares returns the XOR shares of the XOR shared outputs. The
+// share bits are the permute bits of the argument output labels.
+func (c *Circuit) xorShares(labels []ot.Label) []*big.Int {
+	shares := make([]*big.Int, len(c.Outputs))
+
+	var bit int
+	for idx, out := range c.Outputs {
+		if out.Share != ShareXOR {
+			bit += int(out.Type.Bits)
+			continue
+		}
+		shares[idx] = new(big.Int)
+		for i := 0; i < int(out.Type.Bits); i++ {
+			if labels[bit].S() {
+				shares[idx].SetBit(shares[idx], i, 1)
+			}
+			bit++
+		}
+	}
+	return shares
+}
+
+// setShares sets the output values from the non-nil shares.
+func setShares(values, shares []*big.Int) []*big.Int {
+	for idx, share := range shares {
+		if share != nil {
+			values[idx] = share
+		}
+	}
+	return values
+}
@@ -0,0 +1,316 @@
+//
+// stream_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit_test
+
+import (
+	"math/big"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/markkurossi/mpc/circuit"
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+	"github.com/markkurossi/mpc/ot/drbg"
+)
+
+var schemes = []utils.Garbling{
+	utils.HalfGates, utils.ThreeHalves, utils.Classic,
+}
+
+// inputLabels returns the input wire labels for the inputs.
+func inputLabels(circ *circuit.Circuit, garbled *circuit.Garbled,
+	inputs []*big.Int) []ot.Label {
+
+	input := new(big.Int)
+	var shift int
+	for i, arg := range circ.Inputs {
+		input.Or(input, new(big.Int).Lsh(inputs[i], uint(shift)))
+		shift += int(arg.Type.Bits)
+	}
+	wires := make([]ot.Label, circ.NumWires)
+	for i := 0; i < circ.Inputs.Size(); i++ {
+		wires[i] = circuit.LabelForBit(garbled.Wires[i], input.Bit(i) == 1)
+	}
+	return wires
+}
+
+// checkOutputs decodes the output wire labels and compares them to
+// the expected values.
+func checkOutputs(t *testing.T, name string, circ *circuit.Circuit,
+	garbled *circuit.Garbled, wires []ot.Label, expected []*big.Int) {
+	t.Helper()
+
+	result := new(big.Int)
+	base := circ.NumWires - circ.Outputs.Size()
+	for i := 0; i < circ.Outputs.Size(); i++ {
+		bit, err := circuit.BitFromLabel(garbled.Wires[base+i], wires[base+i])
+		if err != nil {
+			t.Fatalf("%s: output %d: %v", name, i, err)
+		}
+		if bit {
+			result.SetBit(result, i, 1)
+		}
+	}
+	values := circ.Outputs.Split(result)
+	for i := range values {
+		if values[i].Cmp(expected[i]) != 0 {
+			t.Errorf("%s: got %v, expected %v", name, values, expected)
+			return
+		}
+	}
+}
+
+func TestGarbleStream(t *testing.T) {
+	circ := compileProtocolTest(t, protocolTestCode)
+
+	inputs := []*big.Int{big.NewInt(0x11223344), big.NewInt(0x55667788)}
+	expected, err := circ.Compute(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key [32]byte
+
+	for _, scheme := range schemes {
+		for _, chunk := range []int{1, 7, 100, circ.NumGates} {
+			garbler, err := circ.NewGarbleStream(nil, key[:], scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			garbled := garbler.Garbled()
+			wires := inputLabels(circ, garbled, inputs)
+
+			evaluator, err := circ.NewEvalStream(key[:], wires, scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for !garbler.Done() {
+				gates, err := garbler.Next(chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := evaluator.Eval(gates); err != nil {
+					t.Fatalf("%v: chunk %d: Eval failed: %v",
+						scheme, chunk, err)
+				}
+			}
+			if !evaluator.Done() {
+				t.Fatalf("%v: chunk %d: evaluator not done", scheme, chunk)
+			}
+			if err := evaluator.Eval(make([][]ot.Label, 1)); err == nil {
+				t.Errorf("%v: chunk %d: extra gates accepted", scheme, chunk)
+			}
+			checkOutputs(t, scheme.String(), circ, garbled, wires, expected)
+		}
+	}
+}
+
+const parallelTestCode = `
+package main
+
+func main(g, e uint256) (uint256, uint256) {
+    return (g & e) | (g ^ e), g * e
+}
+`
+
+func TestGarbleParallel(t *testing.T) {
+	circ := compileProtocolTest(t, parallelTestCode)
+
+	g := new(big.Int).Lsh(big.NewInt(0x11223344), 200)
+	g.Add(g, big.NewInt(0x55667788))
+	e := new(big.Int).Lsh(big.NewInt(0x0badc0de), 150)
+	e.Add(e, big.NewInt(0x7fffffff))
+	inputs := []*big.Int{g, e}
+	expected, err := circ.Compute(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key [32]byte
+	seed := make([]byte, drbg.SeedSize)
+
+	garble := func(scheme utils.Garbling, workers int) *circuit.Garbled {
+		rand, err := drbg.New(drbg.AESCTR, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream, err := circ.NewGarbleStream(rand, key[:], scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream.SetWorkers(workers)
+		gates, err := stream.Next(circ.NumGates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		garbled := stream.Garbled()
+		garbled.Gates = gates
+		return garbled
+	}
+
+	for _, scheme := range schemes {
+		sequential := garble(scheme, 1)
+
+		for _, workers := range []int{2, 3, 8} {
+			garbled := garble(scheme, workers)
+			if !slices.Equal(garbled.Wires, sequential.Wires) {
+				t.Fatalf("%v: workers %d: wire labels differ", scheme, workers)
+			}
+			for i := range garbled.Gates {
+				if !slices.Equal(garbled.Gates[i], sequential.Gates[i]) {
+					t.Fatalf("%v: workers %d: gate %d differs",
+						scheme, workers, i)
+				}
+			}
+
+			wires := inputLabels(circ, garbled, inputs)
+			evaluator, err := circ.NewEvalStream(key[:], wires, scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			evaluator.SetWorkers(workers)
+			if err := evaluator.Eval(garbled.Gates); err != nil {
+				t.Fatalf("%v: workers %d: Eval failed: %v",
+					scheme, workers, err)
+			}
+			checkOutputs(t, scheme.String(), circ, garbled, wires, expected)
+		}
+	}
+}
+
+const threeHalvesTestCode = `
+package main
+
+func main(a, b uint64) (uint64, uint64, bool, uint64) {
+    return a * b, a &^ b, a < b, (a | b) + (a >> 3)
+}
+`
+
+func TestThreeHalves(t *testing.T) {
+	circ := compileProtocolTest(t, threeHalvesTestCode)
+	var key [32]byte
+	r := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 50; i++ {
+		inputs := []*big.Int{
+			new(big.Int).SetUint64(r.Uint64()),
+			new(big.Int).SetUint64(r.Uint64()),
+		}
+		if i < 2 {
+			// All zero and all one inputs.
+			inputs[0].SetUint64(uint64(i) * ^uint64(0))
+			inputs[1].Set(inputs[0])
+		}
+		expected, err := circ.Compute(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream, err := circ.NewGarbleStream(nil, key[:], utils.ThreeHalves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gates, err := stream.Next(circ.NumGates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		garbled := stream.Garbled()
+
+		for idx, gate := range circ.Gates {
+			if gate.Op == circuit.AND && gates[idx][1].D1<<8 != 0 {
+				t.Fatalf("gate %d: control bits overflow: %x",
+					idx, gates[idx][1].D1)
+			}
+		}
+
+		wires := inputLabels(circ, garbled, inputs)
+		evaluator, err := circ.NewEvalStream(key[:], wires, utils.ThreeHalves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := evaluator.Eval(gates); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		checkOutputs(t, "three-halves", circ, garbled, wires, expected)
+
+		// The half-gates evaluator rejects or mis-evaluates the
+		// three-halves tables.
+		wires = inputLabels(circ, garbled, inputs)
+		err = circ.Eval(key[:], wires, gates)
+		if err == nil {
+			base := circ.NumWires - circ.Outputs.Size()
+			for j := 0; j < circ.Outputs.Size(); j++ {
+				_, err = circuit.BitFromLabel(garbled.Wires[base+j],
+					wires[base+j])
+				if err != nil {
+					break
+				}
+			}
+		}
+		if err == nil {
+			t.Errorf("half-gates evaluated three-halves tables")
+		}
+	}
+	_, err := circ.NewGarbleStream(nil, key[:], utils.Garbling(42))
+	if err == nil {
+		t.Errorf("invalid garbling scheme accepted")
+	}
+}
+
+// tableBytes holds the wire size of the AND and OR gate tables of the
+// garbling schemes.
+var tableBytes = map[utils.Garbling]uint64{
+	utils.HalfGates:   32,
+	utils.ThreeHalves: 25,
+	utils.Classic:     64,
+}
+
+func TestGateCosts(t *testing.T) {
+	circ := compileProtocolTest(t, threeHalvesTestCode)
+	if circ.Stats[circuit.INV] == 0 || circ.Stats[circuit.OR] == 0 {
+		t.Fatalf("test circuit without INV and OR gates: %v", circ.Stats)
+	}
+	var key [32]byte
+	nonFree := circ.Stats[circuit.AND] + circ.Stats[circuit.OR]
+
+	for _, scheme := range schemes {
+		gs, err := circuit.NewGarblingScheme(scheme, key[:], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gs.ID() != scheme {
+			t.Errorf("%v: got scheme %v", scheme, gs.ID())
+		}
+		stream, err := circ.NewGarbleStream(nil, key[:], scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gates, err := stream.Next(circ.NumGates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cost uint64
+		for idx, gate := range circ.Gates {
+			expected := gs.TableSize(gate.Op)
+			if len(gates[idx]) != expected {
+				t.Fatalf("%v: gate %d %v: got %d labels, expected %d",
+					scheme, idx, gate.Op, len(gates[idx]), expected)
+			}
+			cost += uint64(gs.TableBytes(gate.Op))
+		}
+		if expected := nonFree * tableBytes[scheme]; cost != expected {
+			t.Errorf("%v: got %d table bytes, expected %d",
+				scheme, cost, expected)
+		}
+		if c := circ.CostFor(gs); c != cost {
+			t.Errorf("%v: got cost %d, expected %d", scheme, c, cost)
+		}
+	}
+	if c := circ.Cost(); c != nonFree*2 {
+		t.Errorf("got half-gates cost %d, expected %d", c, nonFree*2)
+	}
+}
@@ -0,0 +1,375 @@
+//
+// threehalves.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package circuit
+
+// Three-halves garbling of AND gates, based on Rosulek and Roy: "Three
+// Halves Make a Whole? Beating the Half-Gates Lower Bound for Garbled
+// Circuits", CRYPTO 2021.
+//
+// The wire labels are split into the halves L=D0 and R=D1. The
+// evaluator holds the input labels A and B with colors α and β. It
+// computes the output label halves from three hashes, the half label
+// ciphertexts G0, G1, and G2, and the halves of its input labels:
+//
+//   L = H(A) ⊕ H(A⊕B) ⊕ α·G0 ⊕ (α⊕β)·G2 ⊕ R_L·[A_L A_R B_L B_R]
+//   R = H(B) ⊕ H(A⊕B) ⊕ β·G1 ⊕ (α⊕β)·G2 ⊕ R_R·[A_L A_R B_L B_R]
+//
+// The 2×4 bit matrix R of the row (α, β) depends on the garbler's
+// permute bits and on 14 bits that the garbler derives from the
+// permute bits and 2 random bits of the gate. The derivation keeps the
+// matrix of each row in a public 2-dimensional coset where the random
+// bits make it uniformly random, independent of the permute bits. The
+// evaluator needs only the 2 control bits that select the matrix of
+// its row from the coset. The garbler encrypts the control bits of all
+// four rows with row keys derived from the hashes so that the
+// evaluator learns only the control bits of its row.
+//
+// The garbled table has two labels: the first holds G0 and G1, and the
+// second holds G2 and the control byte in the top byte of its R half.
+// On the wire the table takes 25 bytes: three halves and the control
+// byte.
+
+import (
+	"crypto/cipher"
+
+	"github.com/markkurossi/mpc/compiler/utils"
+	"github.com/markkurossi/mpc/ot"
+)
+
+// halfDot returns the linear combination of the label halves selected
+// by the bits of the mask: bit 0 selects L and bit 1 selects R.
+func halfDot(mask uint8, l ot.Label) uint64 {
+	var r uint64
+	if mask&1 != 0 {
+		r ^= l.D0
+	}
+	if mask&2 != 0 {
+		r ^= l.D1
+	}
+	return r
+}
+
+// quadDot returns the linear combination of the halves of a and b
+// selected by the bits of the mask [A_L A_R B_L B_R].
+func quadDot(mask uint8, a, b ot.Label) uint64 {
+	return halfDot(mask, a) ^ halfDot(mask>>2, b)
+}
+
+// threeHalvesGate holds the garbler's linear combinations for one AND
+// gate.
+type threeHalvesGate struct {
+	// dg holds the Δ halves of G0, G1, and G2.
+	dg [3]uint8
+	// e holds the color 0 input label halves of G0, G1, and G2.
+	e [3]uint8
+	// caL and caR hold the color 0 input label halves of the output
+	// label's L and R halves.
+	caL uint8
+	caR uint8
+}
+
+// The 14 bits of the gate are a linear combination of the basis
+// vectors u and v selected by the random bits, and of the vectors ap
+// and aq selected by the permute bits. The vectors keep the matrix of
+// each row in the coset that the combinations of u and v span.
+const (
+	threeHalvesU  uint16 = 0x3e79
+	threeHalvesV  uint16 = 0x29e7
+	threeHalvesAP uint16 = 0x2780
+	threeHalvesAQ uint16 = 0x1027
+)
+
+// threeHalvesBits returns the 14 bits of the gate from the control
+// bits c and the permute bits p and q.
+func threeHalvesBits(c uint8, p, q bool) uint16 {
+	var bits uint16
+	if c&1 != 0 {
+		bits ^= threeHalvesU
+	}
+	if c&2 != 0 {
+		bits ^= threeHalvesV
+	}
+	if p {
+		bits ^= threeHalvesAP
+	}
+	if q {
+		bits ^= threeHalvesAQ
+	}
+	return bits
+}
+
+// threeHalvesRows holds the evaluator's matrices R of the rows (α, β)
+// indexed by the row 2α+β and the row's control bits.
+var threeHalvesRows [4][4]uint8
+
+// threeHalvesPermute holds the control bit differences that the
+// permute bits p and q cause to the control bits of each row.
+var threeHalvesPermute [4][2]uint8
+
+func init() {
+	for i := 0; i < 4; i++ {
+		alpha, beta := i>>1, i&1
+		for c := uint8(0); c < 4; c++ {
+			m := newThreeHalvesGate(threeHalvesBits(c, false, false),
+				false, false)
+			threeHalvesRows[i][c] = m.row(alpha, beta)
+		}
+		for k, pq := range [][2]bool{{true, false}, {false, true}} {
+			m := newThreeHalvesGate(threeHalvesBits(0, pq[0], pq[1]),
+				pq[0], pq[1])
+			row := m.row(alpha, beta)
+			found := false
+			for c := uint8(0); c < 4; c++ {
+				if threeHalvesRows[i][c] == row {
+					threeHalvesPermute[i][k] = c
+					found = true
+					break
+				}
+			}
+			if !found {
+				panic("three-halves: row matrix outside its coset")
+			}
+		}
+	}
+}
+
+// threeHalvesCtrl returns the control bits of the row from the random bits r and
+// the permute bits p and q.
+func threeHalvesCtrl(row int, r uint8, p, q bool) uint8 {
+	if p {
+		r ^= threeHalvesPermute[row][0]
+	}
+	if q {
+		r ^= threeHalvesPermute[row][1]
+	}
+	return r
+}
+
+// newThreeHalvesGate creates the linear combinations from the 14 bits
+// of the gate and the permute bits p and q of the input wires.
+func newThreeHalvesGate(random uint16, p, q bool) *threeHalvesGate {
+	var fa, fb [3]uint8
+	for k := 0; k < 3; k++ {
+		fa[k] = uint8(random>>(2*k)) & 0x3
+		fb[k] = uint8(random>>(6+2*k)) & 0x3
+	}
+	var pl, pr, ql, qr uint8
+	if p {
+		pl, pr = 1, 2
+	}
+	if q {
+		ql, qr = 1, 2
+	}
+	var m threeHalvesGate
+
+	m.dg[0] = 1 ^ fa[2] ^ fb[2] ^ fb[0]
+	m.dg[1] = 2 ^ fa[2] ^ fb[2] ^ fa[1]
+	m.dg[2] = uint8(random>>12) & 0x3
+
+	for k := 0; k < 3; k++ {
+		m.e[k] = (fa[k] ^ m.dg[k]) | (fb[k]^m.dg[k])<<2
+	}
+	m.caL = (ql ^ fa[0] ^ fa[2]) | (pl^fb[2])<<2
+	m.caR = (qr ^ fa[2]) | (pr^fb[1]^fb[2])<<2
+
+	return &m
+}
+
+// row returns the evaluator's matrix R of the row (alpha, beta). The
+// low nibble holds R_L and the high nibble R_R.
+func (m *threeHalvesGate) row(alpha, beta int) uint8 {
+	l := m.caL
+	r := m.caR
+	if alpha == 1 {
+		l ^= m.e[0]
+	}
+	if beta == 1 {
+		r ^= m.e[1]
+	}
+	if alpha != beta {
+		l ^= m.e[2]
+		r ^= m.e[2]
+	}
+	return l | r<<4
+}
+
+// rowKey returns the key that encrypts the control bits of the row
+// (alpha, beta). The hashes hA and hB are the hashes of the input
+// labels with colors alpha and beta.
+func rowKey(hA, hB ot.Label, alpha, beta int) uint8 {
+	return uint8(hA.D1>>(8*beta)^hB.D1>>(8*alpha)) & 0x3
+}
+
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// threeHalves implements the three-halves scheme. The ctrl cipher
+// derives the random bits of the garbler's AND gates from the gate
+// tweaks.
+type threeHalves struct {
+	mmoHash
+	ctrl cipher.Block
+}
+
+// ID implements GarblingScheme.ID.
+func (s *threeHalves) ID() utils.Garbling {
+	return utils.ThreeHalves
+}
+
+// TableSize implements GarblingScheme.TableSize.
+func (s *threeHalves) TableSize(op Operation) int {
+	switch op {
+	case AND, OR:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// TableBytes implements GarblingScheme.TableBytes.
+func (s *threeHalves) TableBytes(op Operation) int {
+	switch op {
+	case AND, OR:
+		return 3*8 + 1
+	default:
+		return 0
+	}
+}
+
+// Tweaks implements GarblingScheme.Tweaks.
+func (s *threeHalves) Tweaks(op Operation) uint32 {
+	switch op {
+	case AND, OR:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Garble implements GarblingScheme.Garble.
+func (s *threeHalves) Garble(g *Gate, wires []ot.Wire, r ot.Label,
+	tweak uint32, data *ot.LabelData) ([]ot.Label, error) {
+	return garbleWithAND(s, g, wires, r, tweak, data)
+}
+
+// Eval implements GarblingScheme.Eval.
+func (s *threeHalves) Eval(g *Gate, wires []ot.Label, row []ot.Label,
+	tweak uint32, data *ot.LabelData) error {
+	return evalWithAND(s, 2, g, wires, row, tweak, data)
+}
+
+func (s *threeHalves) garbleAND(a, b ot.Wire, r ot.Label, tweak uint32,
+	data *ot.LabelData) (ot.Wire, []ot.Label) {
+
+	// Permute bits and the color 0 labels.
+	p := a.L0.S()
+	q := b.L0.S()
+	a0, a1 := a.L0, a.L1
+	if p {
+		a0, a1 = a1, a0
+	}
+	b0, b1 := b.L0, b.L1
+	if q {
+		b0, b1 = b1, b0
+	}
+	x0 := a0
+	x0.Xor(b0)
+	x1 := x0
+	x1.Xor(r)
+
+	t := tweak
+
+	h := [6]ot.Label{a0, a1, b0, b1, x0, x1}
+	s.Hash(h[:], []uint32{t, t, t + 1, t + 1, t + 2, t + 2})
+	hA := [2]ot.Label{h[0], h[1]}
+	hB := [2]ot.Label{h[2], h[3]}
+	hX := [2]ot.Label{h[4], h[5]}
+
+	ot.NewTweak(t).GetData(data)
+	s.ctrl.Encrypt(data[:], data[:])
+	random := data[15] & 0x3
+	m := newThreeHalvesGate(threeHalvesBits(random, p, q), p, q)
+
+	g0 := hA[0].D0 ^ hA[1].D0 ^ halfDot(m.dg[0], r) ^ quadDot(m.e[0], a0, b0)
+	g1 := hB[0].D0 ^ hB[1].D0 ^ halfDot(m.dg[1], r) ^ quadDot(m.e[1], a0, b0)
+	g2 := hX[0].D0 ^ hX[1].D0 ^ halfDot(m.dg[2], r) ^ quadDot(m.e[2], a0, b0)
+
+	var z uint8
+	for alpha := 0; alpha < 2; alpha++ {
+		for beta := 0; beta < 2; beta++ {
+			row := 2*alpha + beta
+			key := rowKey(hA[alpha], hB[beta], alpha, beta)
+			z |= (threeHalvesCtrl(row, random, p, q) ^ key) << (2 * row)
+		}
+	}
+
+	c := ot.Label{
+		D0: hA[0].D0 ^ hX[0].D0 ^ quadDot(m.caL, a0, b0),
+		D1: hB[0].D0 ^ hX[0].D0 ^ quadDot(m.caR, a0, b0),
+	}
+	if p && q {
+		c.Xor(r)
+	}
+	c1 := c
+	c1.Xor(r)
+	return ot.Wire{
+		L0: c,
+		L1: c1,
+	}, []ot.Label{
+		{
+			D0: g0,
+			D1: g1,
+		},
+		{
+			D0: g2,
+			D1: uint64(z) << 56,
+		},
+	}
+}
+
+func (s *threeHalves) evalAND(a, b ot.Label, row []ot.Label, tweak uint32,
+	data *ot.LabelData) ot.Label {
+
+	alpha := bit(a.S())
+	beta := bit(b.S())
+
+	x := a
+	x.Xor(b)
+
+	t := tweak
+
+	h := [3]ot.Label{a, b, x}
+	s.Hash(h[:], []uint32{t, t + 1, t + 2})
+	hA, hB, hX := h[0], h[1], h[2]
+
+	i := 2*alpha + beta
+	ctrl := uint8(row[1].D1>>(56+2*i)) & 0x3
+	m := threeHalvesRows[i][ctrl^rowKey(hA, hB, alpha, beta)]
+
+	l := hA.D0 ^ hX.D0 ^ quadDot(m&0xf, a, b)
+	r := hB.D0 ^ hX.D0 ^ quadDot(m>>4, a, b)
+	if alpha == 1 {
+		l ^= row[0].D0
+	}
+	if beta == 1 {
+		r ^= row[0].D1
+	}
+	if alpha != beta {
+		l ^= row[1].D0
+		r ^= row[1].D0
+	}
+	return ot.Label{
+		D0: l,
+		D1: r,
+	}
+}
@@ -9,8 +9,12 @@ package ast
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/markkurossi/mpc/circuit"
+	"github.com/markkurossi/mpc/compiler/mpa"
 	"github.com/markkurossi/mpc/compiler/ssa"
 	"github.com/markkurossi/mpc/compiler/types"
 	"github.com/markkurossi/mpc/compiler/utils"
@@ -67,12 +71,29 @@ func (pkg *Package) Compile(ctx *Codegen) (*ssa.Program, Annotations, error) {
 	ctx.PushCompilation(gen.NextBlock(block), gen.Block(), nil, main)
 
 	// Arguments.
+	public, err := publicInputs(main)
+	if err != nil {
+		return nil, nil, ctx.Errorf(main, "%s", err)
+	}
 	var inputs circuit.IO
-	for idx, arg := range main.Args {
+	var publicValues map[string]*big.Int
+	var idx int
+	for _, arg := range main.Args {
 		typeInfo, err := arg.Type.Resolve(NewEnv(ctx.Start()), ctx, gen)
 		if err != nil {
 			return nil, nil, ctx.Errorf(arg, "invalid argument type: %s", err)
 		}
+		if public[arg.Name] {
+			err = definePublicInput(ctx, gen, arg, typeInfo)
+			if err != nil {
+				return nil, nil, err
+			}
+			if publicValues == nil {
+				publicValues = make(map[string]*big.Int)
+			}
+			publicValues[arg.Name] = ctx.Params.PublicInputs[arg.Name]
+			continue
+		}
 		if !typeInfo.Concrete() {
 			if ctx.MainInputSizes == nil {
 				return nil, nil,
@@ -105,6 +126,7 @@ func (pkg *Package) Compile(ctx *Codegen) (*ssa.Program, Annotations, error) {
 		}
 
 		inputs = append(inputs, input)
+		idx++
 	}
 
 	// Compile main.
@@ -114,6 +136,10 @@ func (pkg *Package) Compile(ctx *Codegen) (*ssa.Program, Annotations, error) {
 	}
 
 	// Return values
+	attrs, err := outputAttributes(main)
+	if err != nil {
+		return nil, nil, ctx.Errorf(main, "%s", err)
+	}
 	var outputs circuit.IO
 	for idx, rt := range main.Return {
 		if idx >= len(returnVars) {
@@ -146,9 +172,24 @@ func (pkg *Package) Compile(ctx *Codegen) (*ssa.Program, Annotations, error) {
 					v.Type, idx, main)
 		}
 
+		if attrs[idx].Share != circuit.ShareNone &&
+			attrs[idx].Party != circuit.PartyBoth {
+			return nil, nil, ctx.Errorf(main,
+				"shared return value %d of %s assigned to %v",
+				idx, main, attrs[idx].Party)
+		}
+		if attrs[idx].Modulus != nil &&
+			attrs[idx].Modulus.BitLen() > int(v.Type.Bits) {
+			return nil, nil, ctx.Errorf(main,
+				"modulus of return value %d of %s does not fit in %s",
+				idx, main, v.Type)
+		}
 		outputs = append(outputs, circuit.IOArg{
-			Name: v.String(),
-			Type: v.Type,
+			Name:    v.String(),
+			Type:    v.Type,
+			Party:   attrs[idx].Party,
+			Share:   attrs[idx].Share,
+			Modulus: attrs[idx].Modulus,
 		})
 	}
 
@@ -159,6 +200,7 @@ func (pkg *Package) Compile(ctx *Codegen) (*ssa.Program, Annotations, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	program.Public = publicValues
 	if false { // XXX Peephole liveness analysis is broken.
 		err = program.Peephole()
 		if err != nil {
@@ -177,6 +219,185 @@ func (pkg *Package) Compile(ctx *Codegen) (*ssa.Program, Annotations, error) {
 	return program, main.Annotations, nil
 }
 
+// publicInputs returns the public arguments of the main function. The
+// public arguments are specified with annotations:
+//
+//	// @public NAME...
+//
+// The values of the public arguments are set with
+// utils.Params.PublicInputs.
+func publicInputs(main *Func) (map[string]bool, error) {
+	result := make(map[string]bool)
+
+	for _, ann := range main.Annotations {
+		fields := strings.Fields(ann)
+		if len(fields) == 0 || fields[0] != "@public" {
+			continue
+		}
+		for _, name := range fields[1:] {
+			var found bool
+			for _, arg := range main.Args {
+				if arg.Name == name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("@public: unknown argument %s", name)
+			}
+			result[name] = true
+		}
+	}
+	return result, nil
+}
+
+// definePublicInput defines the public argument as a constant with
+// its value from the compiler parameters.
+func definePublicInput(ctx *Codegen, gen *ssa.Generator, arg *Variable,
+	typeInfo types.Info) error {
+
+	value, ok := ctx.Params.PublicInputs[arg.Name]
+	if !ok || value == nil {
+		return ctx.Errorf(arg, "value for public argument %s not set",
+			arg.Name)
+	}
+	if !typeInfo.Concrete() {
+		return ctx.Errorf(arg, "public argument %s has unspecified type",
+			arg.Name)
+	}
+
+	var constVar ssa.Value
+	switch typeInfo.Type {
+	case types.TBool:
+		if value.Sign() < 0 || value.Cmp(big.NewInt(1)) > 0 {
+			return ctx.Errorf(arg, "invalid bool value %v for %s",
+				value, arg.Name)
+		}
+		constVar = gen.Constant(value.Sign() == 1, typeInfo)
+
+	case types.TInt, types.TUint:
+		bits := int(typeInfo.Bits)
+		min := new(big.Int)
+		max := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		if typeInfo.Type == types.TInt {
+			max.Rsh(max, 1)
+			min.Neg(max)
+		}
+		if value.Cmp(min) < 0 || value.Cmp(max) >= 0 {
+			return ctx.Errorf(arg, "value %v overflows %s of %s",
+				value, typeInfo, arg.Name)
+		}
+		constVal, _ := mpa.Parse(value.String(), 10)
+		constVar = gen.Constant(constVal, typeInfo)
+
+		// Keep the declared argument type for small values.
+		constVal.SetTypeSize(typeInfo.Bits)
+		constVar.Type.Bits = typeInfo.Bits
+
+	default:
+		return ctx.Errorf(arg, "unsupported type %s for public argument %s",
+			typeInfo, arg.Name)
+	}
+
+	lValue := constVar
+	lValue.Name = arg.Name
+	ctx.Start().Bindings.Define(lValue, &constVar)
+	gen.AddConstant(constVar)
+
+	return nil
+}
+
+// outputAttributes returns the output attributes of the main
+// function. The attributes are set with annotations:
+//
+//	// @output NAME|INDEX garbler|evaluator|both
+//	// @share NAME|INDEX xor
+//	// @share NAME|INDEX add MODULUS
+//
+// where NAME is the name of a named return value and INDEX is the
+// index of the return value. The @output assigns the output to its
+// parties and @share splits the output into XOR or additive shares
+// modulo MODULUS. The outputs without annotations are revealed to
+// both parties.
+func outputAttributes(main *Func) ([]circuit.IOArg, error) {
+	attrs := make([]circuit.IOArg, len(main.Return))
+
+	for _, ann := range main.Annotations {
+		fields := strings.Fields(ann)
+		if len(fields) == 0 {
+			continue
+		}
+		var idx int
+		var err error
+
+		switch fields[0] {
+		case "@output":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("invalid @output annotation: %s",
+					strings.TrimSpace(ann))
+			}
+			idx, err = returnIndex(main, fields)
+			if err != nil {
+				return nil, err
+			}
+			attrs[idx].Party, err = circuit.ParseParty(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("@output: %s", err)
+			}
+
+		case "@share":
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("invalid @share annotation: %s",
+					strings.TrimSpace(ann))
+			}
+			idx, err = returnIndex(main, fields)
+			if err != nil {
+				return nil, err
+			}
+			attrs[idx].Share, err = circuit.ParseShare(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("@share: %s", err)
+			}
+			switch attrs[idx].Share {
+			case circuit.ShareAdditive:
+				if len(fields) != 4 {
+					return nil, fmt.Errorf("@share: modulus missing: %s",
+						strings.TrimSpace(ann))
+				}
+				modulus, ok := new(big.Int).SetString(fields[3], 0)
+				if !ok || modulus.Sign() <= 0 {
+					return nil, fmt.Errorf("@share: invalid modulus: %s",
+						fields[3])
+				}
+				attrs[idx].Modulus = modulus
+
+			default:
+				if len(fields) != 3 {
+					return nil, fmt.Errorf("invalid @share annotation: %s",
+						strings.TrimSpace(ann))
+				}
+			}
+		}
+	}
+	return attrs, nil
+}
+
+// returnIndex returns the index of the return value of the
+// annotation.
+func returnIndex(main *Func, fields []string) (int, error) {
+	for i, ret := range main.Return {
+		if len(ret.Name) > 0 && ret.Name == fields[1] {
+			return i, nil
+		}
+	}
+	i, err := strconv.Atoi(fields[1])
+	if err != nil || i < 0 || i >= len(main.Return) {
+		return 0, fmt.Errorf("%s: unknown return value %s",
+			fields[0], fields[1])
+	}
+	return i, nil
+}
+
 // Main returns package's main function.
 func (pkg *Package) Main() (*Func, error) {
 	main, ok := pkg.Functions["main"]
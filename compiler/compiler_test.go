@@ -268,3 +268,60 @@ func main(a, b uint64) uint64 {
 		}
 	}
 }
+
+func TestPublicInputs(t *testing.T) {
+	code := `package main
+// @public idx n zero
+func main(a uint257, idx uint8, b uint257, n uint257, c uint8, zero bool) (uint257, uint8) {
+    if zero {
+        return 0, idx
+    }
+    return (a + b) % n, idx + c
+}
+`
+	params := utils.NewParams()
+	params.PublicInputs = map[string]*big.Int{
+		"idx":  big.NewInt(200),
+		"n":    big.NewInt(1000003),
+		"zero": big.NewInt(0),
+	}
+	circ, _, err := New(params).Compile(code, nil)
+	if err != nil {
+		t.Fatalf("failed to compile: %s", err)
+	}
+	if len(circ.Inputs) != 3 {
+		t.Fatalf("unexpected inputs: %v", circ.Inputs)
+	}
+	if len(circ.Public) != 3 || circ.Public["n"].Int64() != 1000003 {
+		t.Errorf("unexpected public inputs: %v", circ.Public)
+	}
+	results, err := circ.Compute([]*big.Int{
+		big.NewInt(1000000), big.NewInt(10), big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatalf("compute failed: %s", err)
+	}
+	if results[0].Int64() != 7 || results[1].Int64() != 201 {
+		t.Errorf("unexpected results: %v", results)
+	}
+
+	params = utils.NewParams()
+	params.PublicInputs = map[string]*big.Int{
+		"idx":  big.NewInt(256),
+		"n":    big.NewInt(1000003),
+		"zero": big.NewInt(0),
+	}
+	_, _, err = New(params).Compile(code, nil)
+	if err == nil {
+		t.Errorf("overflowing public input accepted")
+	}
+
+	params = utils.NewParams()
+	params.PublicInputs = map[string]*big.Int{
+		"idx": big.NewInt(1),
+	}
+	_, _, err = New(params).Compile(code, nil)
+	if err == nil {
+		t.Errorf("missing public input accepted")
+	}
+}
@@ -54,6 +54,7 @@ func (prog *Program) CompileCircuit(params *utils.Params) (
 		}
 	}
 	circ := cc.Compile()
+	circ.Public = prog.Public
 	if params.CircOut != nil {
 		if params.Verbose {
 			fmt.Printf("Serializing circuit...\n")
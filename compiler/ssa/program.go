@@ -29,6 +29,7 @@ type Program struct {
 	InputWires  []*circuits.Wire
 	OutputWires []*circuits.Wire
 	Constants   map[string]ConstantInst
+	Public      map[string]*big.Int
 	Steps       []Step
 	walloc      *WireAllocator
 	calloc      *circuits.Allocator
@@ -12,10 +12,13 @@ import (
 	"crypto/rand"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"regexp"
 	"sort"
 	"strconv"
+
+	"github.com/markkurossi/mpc/ot/drbg"
 )
 
 // Params specify compiler parameters.
@@ -45,6 +48,11 @@ type Params struct {
 
 	OptPruneGates bool
 
+	// PublicInputs specify the values of the public main arguments.
+	// The public arguments are compiled as constants and they are not
+	// circuit inputs. The bool values are specified as 0 and 1.
+	PublicInputs map[string]*big.Int
+
 	BenchmarkCompile bool
 
 	// SymbolIDs contain the mappings from the interned symbols to
@@ -210,6 +218,148 @@ const (
 // concurrent use by multiple modules as they do not modify it.
 type Config struct {
 	Rand io.Reader
+
+	// DRBG specifies the deterministic random bit generator for the
+	// protocol sessions.
+	DRBG drbg.Algorithm
+
+	// Seed specifies a fixed seed for the session DRBGs. If the seed
+	// is unset, each session is seeded from Rand. The fixed seed
+	// makes the protocol runs reproducible and it must be used only
+	// in tests.
+	Seed []byte
+
+	// OutputMode specifies how the circuit outputs are revealed to
+	// the evaluator.
+	OutputMode OutputMode
+
+	// Protocol specifies the two-party computation protocol the
+	// garbler and evaluator run. The peers must select the same
+	// protocol.
+	Protocol Protocol
+
+	// Garbling specifies the garbling scheme.
+	Garbling Garbling
+
+	// AcceptGarbling lists the garbling schemes the party accepts in
+	// the protocol handshake in the order of preference. If the list
+	// is empty, the party accepts only the Garbling scheme. The peers
+	// use the garbler's first scheme that the evaluator accepts.
+	AcceptGarbling []Garbling
+
+	// Checkpoints stores the protocol checkpoints of resumable
+	// sessions. The sessions are not resumable if the store is nil.
+	Checkpoints CheckpointStore
+
+	// Workers specifies the number of workers that garble and
+	// evaluate the gates of each circuit level in parallel. The
+	// values 0 and 1 process the gates sequentially.
+	Workers int
+}
+
+// CheckpointStore stores the protocol checkpoints of resumable
+// sessions. The checkpoints contain secret protocol state and the
+// store must keep them confidential.
+type CheckpointStore interface {
+	// Load returns the checkpoint data for the ID. The function
+	// returns nil data if the checkpoint does not exist.
+	Load(id string) ([]byte, error)
+
+	// Save stores the checkpoint data for the ID.
+	Save(id string, data []byte) error
+
+	// Delete removes the checkpoint of the ID.
+	Delete(id string) error
+}
+
+// OutputMode specifies how the circuit outputs are revealed to the
+// evaluator.
+type OutputMode int
+
+// Output modes.
+const (
+	// OutputGarbler lets the garbler decode the evaluator's output
+	// labels and send the result to the evaluator. The evaluator must
+	// trust the garbler to report the correct result. This is the
+	// default mode.
+	OutputGarbler OutputMode = iota
+
+	// OutputDecode publishes the output decoding information with the
+	// garbled circuit. The evaluator decodes the outputs locally and
+	// detects invalid output labels.
+	OutputDecode
+)
+
+func (mode OutputMode) String() string {
+	switch mode {
+	case OutputDecode:
+		return "decode"
+	case OutputGarbler:
+		return "garbler"
+	default:
+		return fmt.Sprintf("{OutputMode %d}", int(mode))
+	}
+}
+
+// Protocol specifies the two-party computation protocol.
+type Protocol int
+
+// Two-party computation protocols.
+const (
+	// SemiHonest runs Yao's garbled circuit protocol that is secure
+	// against semi-honest parties.
+	SemiHonest Protocol = iota
+
+	// AuthGarbling runs the authenticated garbling protocol of Wang,
+	// Ranellucci, and Katz. The protocol is secure against malicious
+	// parties with a constant-factor overhead over the semi-honest
+	// protocol.
+	AuthGarbling
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case SemiHonest:
+		return "semi-honest"
+	case AuthGarbling:
+		return "auth-garbling"
+	default:
+		return fmt.Sprintf("{Protocol %d}", int(p))
+	}
+}
+
+// Garbling specifies the garbling scheme for AND and OR gates. All
+// schemes garble XOR, XNOR, and INV gates for free.
+type Garbling int
+
+// Garbling schemes.
+const (
+	// HalfGates garbles AND gates with the half-gates scheme of
+	// Zahur, Rosulek, and Evans. The garbled table has two labels.
+	HalfGates Garbling = iota
+
+	// ThreeHalves garbles AND gates with the three-halves scheme of
+	// Rosulek and Roy. The garbled table has three half labels and
+	// the control bits of the evaluator's rows.
+	ThreeHalves
+
+	// Classic garbles AND and OR gates with the four-row tables of
+	// Yao's garbled circuits with point-and-permute. The scheme is
+	// intended for teaching and testing.
+	Classic
+)
+
+func (g Garbling) String() string {
+	switch g {
+	case HalfGates:
+		return "half-gates"
+	case ThreeHalves:
+		return "three-halves"
+	case Classic:
+		return "classic"
+	default:
+		return fmt.Sprintf("{Garbling %d}", int(g))
+	}
 }
 
 // GetRandom returns the source of entropy for garbling, OT, and other
@@ -220,3 +370,12 @@ func (config *Config) GetRandom() io.Reader {
 	}
 	return rand.Reader
 }
+
+// SessionRandom creates a new DRBG for a protocol session. The DRBG is
+// seeded from Seed if it is set and from GetRandom otherwise.
+func (config *Config) SessionRandom() (*drbg.DRBG, error) {
+	if config.Seed != nil {
+		return drbg.New(config.DRBG, config.Seed)
+	}
+	return drbg.NewFromReader(config.DRBG, config.GetRandom())
+}
@@ -0,0 +1,62 @@
+//
+// abort.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package ot
+
+import (
+	"fmt"
+)
+
+// AbortCode specifies the reason for a protocol abort.
+type AbortCode uint32
+
+// Abort reason codes.
+const (
+	AbortUnknown AbortCode = iota
+	AbortInput
+	AbortProtocol
+	AbortMismatch
+	AbortEvaluation
+	AbortCanceled
+)
+
+var abortCodeNames = map[AbortCode]string{
+	AbortUnknown:    "unknown",
+	AbortInput:      "invalid input",
+	AbortProtocol:   "protocol error",
+	AbortMismatch:   "peer mismatch",
+	AbortEvaluation: "evaluation failed",
+	AbortCanceled:   "canceled",
+}
+
+func (code AbortCode) String() string {
+	name, ok := abortCodeNames[code]
+	if ok {
+		return name
+	}
+	return fmt.Sprintf("{AbortCode %d}", uint32(code))
+}
+
+// MaxAbortReason limits the length of the abort reason text.
+const MaxAbortReason = 1024
+
+// abortTopic is the reserved topic of the abort messages. The abort
+// messages use the sequence number 0 which is never used by the
+// protocol messages.
+const abortTopic = "abort"
+
+// AbortError is returned from the connection operations after the
+// peer has aborted the protocol.
+type AbortError struct {
+	Code   AbortCode
+	Reason string
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("peer aborted: %s: %s", e.Code, e.Reason)
+}
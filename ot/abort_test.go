@@ -0,0 +1,72 @@
+//
+// abort_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package ot
+
+import (
+	"crypto/rand"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc"
+)
+
+func TestAbort(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterMpcSessionManagerServer(srv, NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	addr := lis.Addr().String()
+	garbler, err := NewConn(true, addr, t.Name())
+	if err != nil {
+		t.Fatalf("NewConn: %v", err)
+	}
+	defer garbler.Close()
+	evaluator, err := NewConn(false, addr, t.Name())
+	if err != nil {
+		t.Fatalf("NewConn: %v", err)
+	}
+	defer evaluator.Close()
+
+	l0, _ := NewLabel(rand.Reader)
+	l1, _ := NewLabel(rand.Reader)
+
+	errc := make(chan error)
+	go func() {
+		errc <- NewCO(rand.Reader).Send([]Wire{{L0: l0, L1: l1}}, garbler)
+	}()
+
+	start := time.Now()
+	err = evaluator.Abort(AbortInput, errors.New("invalid input value"))
+	if err != nil {
+		t.Fatalf("Abort: %v", err)
+	}
+
+	err = <-errc
+	var abort *AbortError
+	if !errors.As(err, &abort) {
+		t.Fatalf("expected abort, got %v", err)
+	}
+	if abort.Code != AbortInput || abort.Reason != "invalid input value" {
+		t.Errorf("unexpected abort: %v", abort)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("abort took %v", time.Since(start))
+	}
+	if err := garbler.DirectSend(1, "after abort"); !errors.As(err, &abort) {
+		t.Errorf("send after abort: expected abort, got %v", err)
+	}
+}
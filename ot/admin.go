@@ -0,0 +1,141 @@
+package ot
+
+import (
+	"context"
+	"crypto/subtle"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AdminServer implements the messenger admin service. The admin
+// clients authenticate with a bearer token in the authorization
+// metadata.
+type AdminServer struct {
+	pb.UnimplementedMpcSessionAdminServer
+	messenger *MessengerServer
+	token     string
+}
+
+// NewAdminServer creates a new admin server for the messenger. The
+// token authenticates the admin clients.
+func NewAdminServer(messenger *MessengerServer, token string) *AdminServer {
+	return &AdminServer{
+		messenger: messenger,
+		token:     token,
+	}
+}
+
+// AdminContext returns a context that carries the admin token.
+func AdminContext(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		"authorization", "Bearer "+token)
+}
+
+func (a *AdminServer) authorize(ctx context.Context) error {
+	if len(a.token) == 0 {
+		return status.Error(codes.PermissionDenied, "admin API disabled")
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token),
+			[]byte(a.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid credentials")
+}
+
+func (a *AdminServer) ListSessions(
+	ctx context.Context,
+	req *pb.Void,
+) (*pb.SessionList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	sessions := make(map[string]*pb.SessionInfo)
+	for key, item := range a.messenger.db.Items() {
+		cfg, ok := item.Object.(*pb.SessionConfig)
+		if !ok {
+			continue
+		}
+		sessions[key] = &pb.SessionInfo{
+			SessionId:    key,
+			Config:       cfg,
+			ExpireAtUnix: time.Unix(0, item.Expiration).Unix(),
+		}
+	}
+	for sid, stats := range a.messenger.usage.sessionStats() {
+		info, ok := sessions[sid]
+		if !ok {
+			info = &pb.SessionInfo{
+				SessionId: sid,
+			}
+			sessions[sid] = info
+		}
+		info.PendingMessages = stats[0]
+		info.PendingBytes = stats[1]
+	}
+
+	result := &pb.SessionList{}
+	for _, info := range sessions {
+		result.Sessions = append(result.Sessions, info)
+	}
+	sort.Slice(result.Sessions, func(i, j int) bool {
+		return result.Sessions[i].SessionId < result.Sessions[j].SessionId
+	})
+	return result, nil
+}
+
+func (a *AdminServer) ListMessages(
+	ctx context.Context,
+	req *pb.SessionId,
+) (*pb.MessageInfoList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := &pb.MessageInfoList{}
+	for _, msg := range a.messenger.usage.messages(req.Value) {
+		result.Messages = append(result.Messages, &pb.MessageInfo{
+			Topic: msg.Topic,
+			Src:   msg.Src,
+			Dst:   msg.Dst,
+			Seq:   msg.Seq,
+			Size:  uint64(len(msg.Val)),
+			AgeMs: now.Sub(msg.Created).Milliseconds(),
+		})
+	}
+	sort.Slice(result.Messages, func(i, j int) bool {
+		a := result.Messages[i]
+		b := result.Messages[j]
+		if a.Src != b.Src {
+			return a.Src < b.Src
+		}
+		return a.Seq < b.Seq
+	})
+	return result, nil
+}
+
+func (a *AdminServer) ExpireSession(
+	ctx context.Context,
+	req *pb.SessionId,
+) (*pb.ExpireResult, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	found, count := a.messenger.ExpireSession(req.Value)
+	return &pb.ExpireResult{
+		SessionFound:    found,
+		ExpiredMessages: uint64(count),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package ot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func adminContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestAdmin(t *testing.T) {
+	s := NewServer()
+	admin := NewAdminServer(s, "secret")
+	ctx := context.Background()
+
+	sid, err := s.NewSession(ctx, &pb.SessionConfig{Operation: "bip32"})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	for seq := 1; seq <= 2; seq++ {
+		if _, err := s.Inbox(ctx, inboxMessage(sid.Value, seq, 10)); err != nil {
+			t.Fatalf("Inbox failed: %v", err)
+		}
+	}
+	if _, err := s.Inbox(ctx, inboxMessage("anonymous", 1, 5)); err != nil {
+		t.Fatalf("Inbox failed: %v", err)
+	}
+
+	_, err = admin.ListSessions(ctx, &pb.Void{})
+	expectCode(t, err, codes.Unauthenticated)
+	_, err = admin.ListSessions(adminContext("wrong"), &pb.Void{})
+	expectCode(t, err, codes.Unauthenticated)
+
+	actx := adminContext("secret")
+	sessions, err := admin.ListSessions(actx, &pb.Void{})
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions.Sessions) != 2 {
+		t.Fatalf("unexpected # of sessions: %v", sessions.Sessions)
+	}
+	for _, info := range sessions.Sessions {
+		switch info.SessionId {
+		case sid.Value:
+			if info.Config.GetOperation() != "bip32" ||
+				info.PendingMessages != 2 || info.PendingBytes != 20 {
+				t.Errorf("unexpected session info: %v", info)
+			}
+		case "anonymous":
+			if info.Config != nil || info.PendingMessages != 1 {
+				t.Errorf("unexpected session info: %v", info)
+			}
+		default:
+			t.Errorf("unexpected session: %v", info)
+		}
+	}
+
+	msgs, err := admin.ListMessages(actx, sid)
+	if err != nil {
+		t.Fatalf("ListMessages failed: %v", err)
+	}
+	if len(msgs.Messages) != 2 || msgs.Messages[0].Seq != 1 ||
+		msgs.Messages[1].Seq != 2 || msgs.Messages[0].Size != 10 {
+		t.Fatalf("unexpected messages: %v", msgs.Messages)
+	}
+
+	result, err := admin.ExpireSession(actx, sid)
+	if err != nil {
+		t.Fatalf("ExpireSession failed: %v", err)
+	}
+	if !result.SessionFound || result.ExpiredMessages != 2 {
+		t.Fatalf("unexpected expire result: %v", result)
+	}
+	_, err = s.GetSessionConfig(ctx, sid)
+	expectCode(t, err, codes.NotFound)
+
+	sessions, err = admin.ListSessions(actx, &pb.Void{})
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions.Sessions) != 1 {
+		t.Fatalf("unexpected # of sessions: %v", sessions.Sessions)
+	}
+}
@@ -112,24 +112,35 @@ func (cl *MessengerClient) DirectSend(
 	dst int,
 	seq int,
 ) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-	stub := cl.stub()
-
-	buf0 := new(bytes.Buffer)
-	err := gob.NewEncoder(buf0).Encode(obj)
+	data, err := encodeMessage(obj)
 	if err != nil {
 		err = errors.Wrapf(err, "[DirectSend] failed to serialize object: "+
 			"query = (%s, %s, %d, %d, %d)", sid, topic, src, dst, seq)
 		return err
 	}
+	return cl.SendBytes(data, sid, topic, src, dst, seq)
+}
+
+// SendBytes posts the encoded message data to the messenger.
+func (cl *MessengerClient) SendBytes(
+	data []byte,
+	sid string,
+	topic string,
+	src int,
+	dst int,
+	seq int,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	stub := cl.stub()
+
 	req0 := &pb.Message{
 		Sid: sid, Topic: topic, Src: uint64(src), Dst: uint64(dst), Seq: uint64(seq),
-		Val: buf0.Bytes(),
+		Val: data,
 	}
 	req := &pb.VecMessage{Values: []*pb.Message{req0}}
 
-	if _, err = stub.Inbox(ctx, req); err != nil {
+	if _, err := stub.Inbox(ctx, req); err != nil {
 		err = errors.Wrapf(err, "[ DirectSend ] failed to post object: "+
 			"query = (%s, %s, %d, %d, %d)", sid, topic, src, dst, seq)
 		return err
@@ -152,7 +163,42 @@ func (cl *MessengerClient) DirectRecv(
 	dst int,
 	seq int,
 ) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	data, err := cl.RecvBytes(sid, topic, src, dst, seq)
+	if err != nil {
+		return err
+	}
+	err = decodeMessage(data, out)
+	if err != nil {
+		err = errors.Wrapf(err, "[ DirectRecv ] failed to deserialize object: "+
+			"query = (%s, %s, %d, %d, %d)", sid, topic, src, dst, seq)
+		return err
+	}
+	return nil
+}
+
+// RecvBytes receives the encoded message data from the messenger.
+func (cl *MessengerClient) RecvBytes(
+	sid string,
+	topic string,
+	src int,
+	dst int,
+	seq int,
+) ([]byte, error) {
+	return cl.RecvBytesContext(context.Background(), sid, topic, src, dst,
+		seq)
+}
+
+// RecvBytesContext is like RecvBytes but the receive is canceled when
+// the argument context is done.
+func (cl *MessengerClient) RecvBytesContext(
+	ctx context.Context,
+	sid string,
+	topic string,
+	src int,
+	dst int,
+	seq int,
+) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 	stub := cl.stub()
 
@@ -166,31 +212,23 @@ func (cl *MessengerClient) DirectRecv(
 	if err != nil {
 		err = errors.Wrapf(err, "[ DirectRecv ] failed to post object: "+
 			"query = (%s, %s, %d, %d, %d)", sid, topic, src, dst, seq)
-		return err
+		return nil, err
 	}
 	if len(resp0.Values) != 1 {
-		err = errors.Wrapf(err, "[ DirectRecv ] received bad response: "+
+		err = errors.Newf("[ DirectRecv ] received bad response: "+
 			"query = (%s, %s, %d, %d, %d)", sid, topic, src, dst, seq)
-		return err
+		return nil, err
 	}
 	resp := resp0.Values[0].Val
-	nbytes := len(resp)
 
-	buf := bytes.NewBuffer(resp)
-	err = gob.NewDecoder(buf).Decode(out)
-	if err != nil {
-		err = errors.Wrapf(err, "[ DirectRecv ] failed to deserialize object: "+
-			"query = (%s, %s, %d, %d, %d)", sid, topic, src, dst, seq)
-		return err
-	}
 	if os.Getenv("GARBLED_VERBOSE") != "" {
 		log.Printf(
 			"finish DirectRecv. sid=[%s], topic=[%s], src=%d, dst=%d, seq=%d, size=%dbytes.",
-			sid, topic, src, dst, seq, nbytes,
+			sid, topic, src, dst, seq, len(resp),
 		)
 	}
 
-	return nil
+	return resp, nil
 }
 
 func (cl *MessengerClient) MpcClear() {
@@ -198,6 +236,18 @@ func (cl *MessengerClient) MpcClear() {
 	cl.rx = make(map[string]any)
 }
 
+func encodeMessage(obj any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeMessage(data []byte, out any) error {
+	return gob.NewDecoder(bytes.NewBuffer(data)).Decode(out)
+}
+
 func PrimaryKey(args ...any) string {
 	ha, _ := blake2b.New256(nil)
 	for _, arg := range args {
@@ -232,6 +232,14 @@ func NewCO(rand io.Reader) *CO {
 	}
 }
 
+// WithRandom returns a copy of the OT that uses the argument source
+// of randomness.
+func (co *CO) WithRandom(rand io.Reader) *CO {
+	result := *co
+	result.rand = rand
+	return &result
+}
+
 // Send sends the wire labels with OT.
 func (co *CO) Send(wires []Wire, conn *Conn) error {
 	setup, err := GenerateCOSenderSetup(co.rand, co.curve)
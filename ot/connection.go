@@ -9,9 +9,21 @@
 package ot
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/gob"
+	"sync/atomic"
+	"time"
+
 	"github.com/cockroachdb/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// ErrResumeMismatch is returned if a resumed session sends a message
+// that differs from the message delivered before the restart.
+var ErrResumeMismatch = errors.New("resumed message differs from delivered message")
+
 // Conn implements a protocol connection.
 type Conn struct {
 	conn *MessengerClient
@@ -20,12 +32,55 @@ type Conn struct {
 
 	nsend int
 	nrecv int
+
+	transcript *Transcript
+
+	// resume specifies if the connection replays a resumed session.
+	resume bool
+
+	// recorder records the protocol messages and replay replays the
+	// recorded messages of the session. The header is the recording
+	// header. The recorder writes it with the session DRBG or before
+	// the first recorded message.
+	recorder *gob.Encoder
+	replay   *gob.Decoder
+	header   *RecordingHeader
+	written  bool
+	session  string
+
+	// The ctx is canceled with the peer's AbortError when the peer
+	// aborts the protocol.
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	aborted atomic.Bool
+
+	// The channels of the connection have the parent connection and
+	// their topics are prefixed with the channel name.
+	parent *Conn
+	prefix string
 }
 
 func (c *Conn) SessionId() string {
+	if c.conn == nil {
+		return c.session
+	}
 	return c.conn.SessionId
 }
 
+// Counters returns the number of messages sent and received over the
+// connection.
+func (c *Conn) Counters() (nsend, nrecv int) {
+	return c.nsend, c.nrecv
+}
+
+// SetResume sets the connection to replay a resumed session. In the
+// resume mode, the messages delivered before the restart are re-read
+// from the messenger and they must match the messages sent in the
+// replay.
+func (c *Conn) SetResume(resume bool) {
+	c.resume = resume
+}
+
 // NewConn creates a new connection around the argument connection.
 func NewConn(isGarbler bool, hostport, sid string) (*Conn, error) {
 	conn := new(MessengerClient)
@@ -44,20 +99,129 @@ func NewConn(isGarbler bool, hostport, sid string) (*Conn, error) {
 		return nil, err
 	}
 
+	ctx, cancel := context.WithCancelCause(context.Background())
 	c := &Conn{
-		conn:  conn,
-		nsend: 0,
-		nrecv: 0,
+		conn:       conn,
+		nsend:      0,
+		nrecv:      0,
+		transcript: NewTranscript(),
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 	if isGarbler {
 		c.je, c.tu = 1, 2
 	} else {
 		c.je, c.tu = 2, 1
 	}
+	go c.watchAbort()
 
 	return c, nil
 }
 
+// Channel creates a message channel over the connection. The channel
+// has its own message sequence numbers and transcript, and its topics
+// are separated from the connection's topics by the channel name. The
+// channels of a connection can be used concurrently with each other.
+// The channel shares the peer abort state of the connection and it is
+// closed when the connection is closed. The recording and replaying
+// connections do not support channels.
+func (c *Conn) Channel(name string) (*Conn, error) {
+	if c.recorder != nil || c.replay != nil {
+		return nil, errors.New("channels of recorded connections not supported")
+	}
+	root := c
+	if c.parent != nil {
+		root = c.parent
+	}
+	return &Conn{
+		conn:       c.conn,
+		je:         c.je,
+		tu:         c.tu,
+		transcript: NewTranscript(),
+		resume:     c.resume,
+		ctx:        c.ctx,
+		cancel:     c.cancel,
+		parent:     root,
+		prefix:     c.prefix + name + "/",
+	}, nil
+}
+
+// watchAbort waits for the peer's abort message and cancels the
+// connection context with the peer's AbortError.
+func (c *Conn) watchAbort() {
+	for {
+		data, err := c.conn.RecvBytesContext(c.ctx, c.conn.SessionId,
+			abortTopic, c.tu, c.je, 0)
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			// Receive timed out or the messenger is unavailable.
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		abort := new(AbortError)
+		if err := decodeMessage(data, abort); err != nil {
+			abort = &AbortError{
+				Code:   AbortUnknown,
+				Reason: "malformed abort message",
+			}
+		}
+		c.cancel(abort)
+		return
+	}
+}
+
+// peerAbort returns the peer's AbortError or nil if the peer has not
+// aborted the protocol.
+func (c *Conn) peerAbort() *AbortError {
+	var abort *AbortError
+	if errors.As(context.Cause(c.ctx), &abort) {
+		return abort
+	}
+	return nil
+}
+
+// Abort posts an abort message with the reason code and the error
+// text to the peer. The function does nothing if the error is the
+// peer's abort or if the connection is already aborted.
+func (c *Conn) Abort(code AbortCode, err error) error {
+	var abort *AbortError
+	if errors.As(err, &abort) || c.replay != nil {
+		return nil
+	}
+	root := c
+	if c.parent != nil {
+		root = c.parent
+	}
+	if !root.aborted.CompareAndSwap(false, true) {
+		return nil
+	}
+	var reason string
+	if err != nil {
+		reason = err.Error()
+	}
+	if len(reason) > MaxAbortReason {
+		reason = reason[:MaxAbortReason]
+	}
+	data, err := encodeMessage(&AbortError{
+		Code:   code,
+		Reason: reason,
+	})
+	if err != nil {
+		return errors.Wrap(err, "in mpc_hd::Conn::Abort()")
+	}
+	err = c.conn.SendBytes(data, c.conn.SessionId, abortTopic, c.je, c.tu, 0)
+	if err != nil {
+		return errors.Wrap(err, "in mpc_hd::Conn::Abort()")
+	}
+	return nil
+}
+
 // NeedSpace ensures the write buffer has space for count bytes. The
 // function flushes the output if needed.
 // 一处使用: stream_garble.go
@@ -78,25 +242,107 @@ func (c *Conn) Fill(n int) error {
 
 // Close flushes any pending data and closes the connection.
 func (c *Conn) Close() error {
+	c.cancel(nil)
+	if c.conn == nil {
+		return nil
+	}
 	return c.conn.Close()
 }
 
 func (c *Conn) DirectSend(snd any, topic string) error {
+	topic = c.prefix + topic
+	if abort := c.peerAbort(); abort != nil {
+		return errors.Wrapf(abort, "in mpc_hd::Conn::DirectSend(&self, any)")
+	}
 	conn := c.conn
 	c.nsend += 1
-	err := conn.DirectSend(snd, conn.SessionId, topic, c.je, c.tu, c.nsend)
+	data, err := encodeMessage(snd)
+	if err != nil {
+		return errors.Wrapf(err, "in mpc_hd::Conn::DirectSend(&self, any)")
+	}
+	if c.replay != nil {
+		err = c.replaySend(topic, c.nsend, data)
+	} else {
+		err = conn.SendBytes(data, conn.SessionId, topic, c.je, c.tu, c.nsend)
+		if err != nil && c.resume && status.Code(err) == codes.AlreadyExists {
+			err = c.checkDelivered(topic, data)
+		}
+	}
+	if err == nil {
+		err = c.record(true, topic, c.nsend, data)
+	}
 	if err != nil {
 		return errors.Wrapf(err, "in mpc_hd::Conn::DirectSend(&self, any)")
 	}
+	c.transcript.Add(c.je, c.tu, topic, c.nsend, data)
+	return nil
+}
+
+// checkDelivered verifies that the message delivered before the
+// restart matches the replayed message data.
+func (c *Conn) checkDelivered(topic string, data []byte) error {
+	delivered, err := c.conn.RecvBytesContext(c.ctx, c.conn.SessionId, topic,
+		c.je, c.tu, c.nsend)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(delivered, data) != 1 {
+		return ErrResumeMismatch
+	}
 	return nil
 }
 
 func (c *Conn) DirectRecv(rcv any, topic string) error {
-	conn := c.conn
-	c.nrecv += 1
-	err := conn.DirectRecv(rcv, conn.SessionId, topic, c.tu, c.je, c.nrecv)
+	topic = c.prefix + topic
+	data, err := c.recvBytes(topic)
+	if err == nil {
+		err = c.record(false, topic, c.nrecv, data)
+	}
 	if err != nil {
 		return errors.Wrapf(err, "in mpc_hd::Conn::DirectRecv(&self, any)")
 	}
+	c.transcript.Add(c.tu, c.je, topic, c.nrecv, data)
+	if err := decodeMessage(data, rcv); err != nil {
+		return errors.Wrapf(err, "in mpc_hd::Conn::DirectRecv(&self, any)")
+	}
+	return nil
+}
+
+func (c *Conn) recvBytes(topic string) ([]byte, error) {
+	c.nrecv += 1
+	if c.replay != nil {
+		rec, err := c.replayNext(false, topic, c.nrecv)
+		if err != nil {
+			return nil, err
+		}
+		return rec.Data, nil
+	}
+	conn := c.conn
+	data, err := conn.RecvBytesContext(c.ctx, conn.SessionId, topic,
+		c.tu, c.je, c.nrecv)
+	if err != nil {
+		if abort := c.peerAbort(); abort != nil {
+			err = abort
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// ConfirmTranscript runs the final transcript confirmation round with
+// the peer. The function returns ErrTranscriptMismatch if the peers
+// have seen different messages.
+func (c *Conn) ConfirmTranscript() error {
+	sum := c.transcript.Sum()
+	if err := c.DirectSend(sum, "transcript"); err != nil {
+		return errors.Wrap(err, "in mpc_hd::Conn::ConfirmTranscript()")
+	}
+	var peer []byte
+	if err := c.DirectRecv(&peer, "transcript"); err != nil {
+		return errors.Wrap(err, "in mpc_hd::Conn::ConfirmTranscript()")
+	}
+	if subtle.ConstantTimeCompare(sum, peer) != 1 {
+		return ErrTranscriptMismatch
+	}
 	return nil
 }
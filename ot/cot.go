@@ -0,0 +1,349 @@
+//
+// cot.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package ot
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/ot/drbg"
+)
+
+// ErrCOTCheck is returned when the correlated OT receiver fails the
+// consistency check of the extension.
+var ErrCOTCheck = errors.New("correlated OT consistency check failed")
+
+// cotK specifies the number of base OTs and the security parameter of
+// the OT extension. The extension runs cotK extra OTs to mask the
+// consistency check.
+const cotK = 128
+
+// COTSender implements the sender of the correlated OT extension of
+// Ishai, Kilian, Nissim, and Petrank: "Extending Oblivious Transfers
+// Efficiently", CRYPTO 2003, with the consistency check of Keller,
+// Orsini, and Scholl: "Actively Secure OT Extension with Optimal
+// Overhead", CRYPTO 2015. The sender gets the keys K of the
+// transfers and the receiver gets the labels K ⊕ bΔ where b is its
+// choice bit and Δ is the sender's global correlation.
+type COTSender struct {
+	rand  io.Reader
+	conn  *Conn
+	delta Label
+	prg   [cotK]*drbg.DRBG
+}
+
+// COTReceiver implements the receiver of the correlated OT extension.
+type COTReceiver struct {
+	rand io.Reader
+	conn *Conn
+	prg  [cotK][2]*drbg.DRBG
+}
+
+// NewCOTSender creates a new correlated OT sender with the global
+// correlation delta. The function runs the base OTs with the peer's
+// NewCOTReceiver as the base OT receiver.
+func NewCOTSender(co *CO, conn *Conn, delta Label) (*COTSender, error) {
+	flags := make([]bool, cotK)
+	for i := range flags {
+		flags[i] = labelBit(delta, i)
+	}
+	seeds := make([]Label, cotK)
+	if err := co.Receive(flags, seeds, conn); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::NewCOTSender(...), when receiving base OT seeds.")
+		return nil, err
+	}
+	s := &COTSender{
+		rand:  co.rand,
+		conn:  conn,
+		delta: delta,
+	}
+	for i, seed := range seeds {
+		prg, err := newCOTPRG(seed)
+		if err != nil {
+			return nil, err
+		}
+		s.prg[i] = prg
+	}
+	return s, nil
+}
+
+// Delta returns the sender's global correlation.
+func (s *COTSender) Delta() Label {
+	return s.delta
+}
+
+// Send runs n correlated OTs and returns the sender's keys.
+func (s *COTSender) Send(n int) ([]Label, error) {
+	m := n + cotK
+	words := (m + 63) / 64
+
+	var u [][]uint64
+	if err := s.conn.DirectRecv(&u, "cot extension"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::COTSender::Send(&self, int), when receiving extension.")
+		return nil, err
+	}
+	if len(u) != cotK {
+		return nil, errors.Newf("peer sent %d extension rows, expected %d",
+			len(u), cotK)
+	}
+	var q [cotK][]uint64
+	for i := range q {
+		if len(u[i]) != words {
+			return nil, errors.Newf("peer sent %d extension words, expected %d",
+				len(u[i]), words)
+		}
+		q[i] = prgWords(s.prg[i], words)
+		if labelBit(s.delta, i) {
+			for w := range q[i] {
+				q[i][w] ^= u[i][w]
+			}
+		}
+	}
+	keys := transposeRows(&q, m)
+
+	var seed [drbg.SeedSize]byte
+	if _, err := io.ReadFull(s.rand, seed[:]); err != nil {
+		return nil, err
+	}
+	if err := s.conn.DirectSend(seed[:], "cot challenge"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::COTSender::Send(&self, int), when sending challenge.")
+		return nil, err
+	}
+	var check []Label
+	if err := s.conn.DirectRecv(&check, "cot check"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::COTSender::Send(&self, int), when receiving check.")
+		return nil, err
+	}
+	if len(check) != 2 {
+		return nil, errors.Newf("peer sent %d check values, expected 2",
+			len(check))
+	}
+	chi, err := drbg.New(drbg.AESCTR, seed[:])
+	if err != nil {
+		return nil, err
+	}
+	var sum Label
+	for _, key := range keys {
+		c, err := NewLabel(chi)
+		if err != nil {
+			return nil, err
+		}
+		sum.Xor(gfMul(c, key))
+	}
+	expected := check[1]
+	expected.Xor(gfMul(check[0], s.delta))
+	if !sum.Equal(expected) {
+		return nil, ErrCOTCheck
+	}
+	return keys[:n], nil
+}
+
+// NewCOTReceiver creates a new correlated OT receiver. The function
+// runs the base OTs with the peer's NewCOTSender as the base OT
+// sender.
+func NewCOTReceiver(co *CO, conn *Conn) (*COTReceiver, error) {
+	wires := make([]Wire, cotK)
+	for i := range wires {
+		l0, err := NewLabel(co.rand)
+		if err != nil {
+			return nil, err
+		}
+		l1, err := NewLabel(co.rand)
+		if err != nil {
+			return nil, err
+		}
+		wires[i] = Wire{L0: l0, L1: l1}
+	}
+	if err := co.Send(wires, conn); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::NewCOTReceiver(...), when sending base OT seeds.")
+		return nil, err
+	}
+	r := &COTReceiver{
+		rand: co.rand,
+		conn: conn,
+	}
+	for i, wire := range wires {
+		prg0, err := newCOTPRG(wire.L0)
+		if err != nil {
+			return nil, err
+		}
+		prg1, err := newCOTPRG(wire.L1)
+		if err != nil {
+			return nil, err
+		}
+		r.prg[i] = [2]*drbg.DRBG{prg0, prg1}
+	}
+	return r, nil
+}
+
+// Receive runs the correlated OTs for the choice bits and returns the
+// receiver's labels.
+func (r *COTReceiver) Receive(choices []bool) ([]Label, error) {
+	m := len(choices) + cotK
+	words := (m + 63) / 64
+
+	// The cotK extra choices are random and they mask the check.
+	bits := make([]uint64, words)
+	for i, c := range choices {
+		if c {
+			bits[i/64] |= 1 << (i % 64)
+		}
+	}
+	var mask [cotK / 8]byte
+	if _, err := io.ReadFull(r.rand, mask[:]); err != nil {
+		return nil, err
+	}
+	for i := 0; i < cotK; i++ {
+		if mask[i/8]&(1<<(i%8)) != 0 {
+			j := len(choices) + i
+			bits[j/64] |= 1 << (j % 64)
+		}
+	}
+
+	var t [cotK][]uint64
+	u := make([][]uint64, cotK)
+	for i := range t {
+		t[i] = prgWords(r.prg[i][0], words)
+		u[i] = prgWords(r.prg[i][1], words)
+		for w := range u[i] {
+			u[i][w] ^= t[i][w] ^ bits[w]
+		}
+	}
+	if err := r.conn.DirectSend(u, "cot extension"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::COTReceiver::Receive(&self, []bool), when sending extension.")
+		return nil, err
+	}
+	labels := transposeRows(&t, m)
+
+	var seed []byte
+	if err := r.conn.DirectRecv(&seed, "cot challenge"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::COTReceiver::Receive(&self, []bool), when receiving challenge.")
+		return nil, err
+	}
+	chi, err := drbg.New(drbg.AESCTR, seed)
+	if err != nil {
+		return nil, err
+	}
+	var x, sum Label
+	for j, label := range labels {
+		c, err := NewLabel(chi)
+		if err != nil {
+			return nil, err
+		}
+		if bits[j/64]&(1<<(j%64)) != 0 {
+			x.Xor(c)
+		}
+		sum.Xor(gfMul(c, label))
+	}
+	if err := r.conn.DirectSend([]Label{x, sum}, "cot check"); err != nil {
+		err = errors.Wrap(err,
+			"in mpc_hd::COTReceiver::Receive(&self, []bool), when sending check.")
+		return nil, err
+	}
+	return labels[:len(choices)], nil
+}
+
+// newCOTPRG creates the pseudorandom generator that expands the base
+// OT seed into the extension rows.
+func newCOTPRG(seed Label) (*drbg.DRBG, error) {
+	var data LabelData
+	sum := sha256.Sum256(seed.Bytes(&data))
+	return drbg.New(drbg.AESCTR, sum[:])
+}
+
+// prgWords reads n words from the pseudorandom generator.
+func prgWords(prg *drbg.DRBG, n int) []uint64 {
+	buf := make([]byte, n*8)
+	prg.Read(buf)
+	result := make([]uint64, n)
+	for i := range result {
+		result[i] = binary.LittleEndian.Uint64(buf[i*8:])
+	}
+	return result
+}
+
+// transposeRows transposes the cotK rows of m bits into m labels. The
+// bit i of the label j is the bit j of the row i.
+func transposeRows(rows *[cotK][]uint64, m int) []Label {
+	result := make([]Label, m)
+	for i, row := range rows {
+		for j := range result {
+			if row[j/64]&(1<<(j%64)) != 0 {
+				setLabelBit(&result[j], i)
+			}
+		}
+	}
+	return result
+}
+
+// labelBit returns the bit i of the label. The bits 0-63 are the bits
+// of D1 and the bits 64-127 are the bits of D0.
+func labelBit(l Label, i int) bool {
+	if i < 64 {
+		return l.D1&(1<<i) != 0
+	}
+	return l.D0&(1<<(i-64)) != 0
+}
+
+// setLabelBit sets the bit i of the label.
+func setLabelBit(l *Label, i int) {
+	if i < 64 {
+		l.D1 |= 1 << i
+	} else {
+		l.D0 |= 1 << (i - 64)
+	}
+}
+
+// gfMul multiplies the labels as elements of GF(2^128) with the
+// reduction polynomial x^128 + x^7 + x^2 + x + 1. The label bit i is
+// the coefficient of x^i.
+func gfMul(a, b Label) Label {
+	h0, l0 := clmul(a.D1, b.D1)
+	h1, l1 := clmul(a.D1, b.D0)
+	h2, l2 := clmul(a.D0, b.D1)
+	h3, l3 := clmul(a.D0, b.D0)
+
+	r0 := l0
+	r1 := h0 ^ l1 ^ l2
+	r2 := h1 ^ h2 ^ l3
+	r3 := h3
+
+	// x^128 = x^7 + x^2 + x + 1
+	r1 ^= r3 ^ (r3 << 1) ^ (r3 << 2) ^ (r3 << 7)
+	r2 ^= (r3 >> 63) ^ (r3 >> 62) ^ (r3 >> 57)
+	r0 ^= r2 ^ (r2 << 1) ^ (r2 << 2) ^ (r2 << 7)
+	r1 ^= (r2 >> 63) ^ (r2 >> 62) ^ (r2 >> 57)
+
+	return Label{
+		D0: r1,
+		D1: r0,
+	}
+}
+
+// clmul computes the carry-less product of the words.
+func clmul(a, b uint64) (hi, lo uint64) {
+	for i := 0; i < 64; i++ {
+		if b&(1<<i) != 0 {
+			lo ^= a << i
+			if i > 0 {
+				hi ^= a >> (64 - i)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,134 @@
+//
+// cot_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package ot
+
+import (
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc"
+)
+
+func TestGFMul(t *testing.T) {
+	one := Label{D1: 1}
+	for i := 0; i < 100; i++ {
+		a, _ := NewLabel(rand.Reader)
+		b, _ := NewLabel(rand.Reader)
+		c, _ := NewLabel(rand.Reader)
+
+		if !gfMul(a, one).Equal(a) {
+			t.Fatalf("%v*1 != %v", a, a)
+		}
+		if !gfMul(a, b).Equal(gfMul(b, a)) {
+			t.Fatalf("%v*%v not commutative", a, b)
+		}
+		bc := b
+		bc.Xor(c)
+		sum := gfMul(a, b)
+		sum.Xor(gfMul(a, c))
+		if !gfMul(a, bc).Equal(sum) {
+			t.Fatalf("%v*(%v+%v) not distributive", a, b, c)
+		}
+		if !gfMul(gfMul(a, b), c).Equal(gfMul(a, gfMul(b, c))) {
+			t.Fatalf("%v*%v*%v not associative", a, b, c)
+		}
+	}
+	// x^127 * x = x^7 + x^2 + x + 1
+	x127 := Label{D0: 1 << 63}
+	x := Label{D1: 2}
+	if r := gfMul(x127, x); !r.Equal(Label{D1: 0x87}) {
+		t.Errorf("x^127*x = %v", r)
+	}
+}
+
+func TestCOT(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterMpcSessionManagerServer(srv, NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	addr := lis.Addr().String()
+	sconn, err := NewConn(true, addr, t.Name())
+	if err != nil {
+		t.Fatalf("NewConn: %v", err)
+	}
+	defer sconn.Close()
+	rconn, err := NewConn(false, addr, t.Name())
+	if err != nil {
+		t.Fatalf("NewConn: %v", err)
+	}
+	defer rconn.Close()
+
+	delta, _ := NewLabel(rand.Reader)
+	const n = 1000
+	choices := make([]bool, n)
+	var buf [n / 8]byte
+	rand.Read(buf[:])
+	for i := range choices {
+		choices[i] = buf[i/8]&(1<<(i%8)) != 0
+	}
+
+	type result struct {
+		keys []Label
+		err  error
+	}
+	resc := make(chan result)
+	go func() {
+		var res result
+		defer func() { resc <- res }()
+		sender, err := NewCOTSender(NewCO(rand.Reader), sconn, delta)
+		if err != nil {
+			res.err = err
+			return
+		}
+		for i := 0; i < 2; i++ {
+			keys, err := sender.Send(n)
+			if err != nil {
+				res.err = err
+				return
+			}
+			res.keys = append(res.keys, keys...)
+		}
+	}()
+
+	receiver, err := NewCOTReceiver(NewCO(rand.Reader), rconn)
+	if err != nil {
+		t.Fatalf("NewCOTReceiver: %v", err)
+	}
+	var labels []Label
+	for i := 0; i < 2; i++ {
+		l, err := receiver.Receive(choices)
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		labels = append(labels, l...)
+	}
+	res := <-resc
+	if res.err != nil {
+		t.Fatalf("Send: %v", res.err)
+	}
+	if len(res.keys) != len(labels) {
+		t.Fatalf("got %d keys, %d labels", len(res.keys), len(labels))
+	}
+	for i, key := range res.keys {
+		if choices[i%n] {
+			key.Xor(delta)
+		}
+		if !key.Equal(labels[i]) {
+			t.Fatalf("COT %d: key %v, label %v, choice %v",
+				i, res.keys[i], labels[i], choices[i%n])
+		}
+	}
+}
@@ -0,0 +1,158 @@
+//
+// drbg.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+// Package drbg implements deterministic random bit generators for
+// garbling and OT randomness. The generators output the key stream of
+// a stream cipher, keyed with a 256-bit seed.
+package drbg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// SeedSize specifies the DRBG seed size in bytes.
+const SeedSize = 32
+
+// bufSize specifies the size of the key stream buffer. The generator
+// produces the key stream in buffer-sized blocks so that small reads,
+// like the 16-byte wire labels, are cheap.
+const bufSize = 4096
+
+// Algorithm specifies the DRBG algorithm.
+type Algorithm int
+
+// DRBG algorithms.
+const (
+	AESCTR Algorithm = iota
+	ChaCha20
+)
+
+var algorithmNames = map[Algorithm]string{
+	AESCTR:   "aes-ctr",
+	ChaCha20: "chacha20",
+}
+
+func (alg Algorithm) String() string {
+	name, ok := algorithmNames[alg]
+	if ok {
+		return name
+	}
+	return fmt.Sprintf("{Algorithm %d}", int(alg))
+}
+
+// ParseAlgorithm parses the DRBG algorithm name.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for alg, n := range algorithmNames {
+		if n == name {
+			return alg, nil
+		}
+	}
+	return AESCTR, fmt.Errorf("invalid DRBG algorithm: %s", name)
+}
+
+// DRBG implements a deterministic random bit generator. It is safe
+// for concurrent use but concurrent readers get the random bytes in
+// nondeterministic order.
+type DRBG struct {
+	m      sync.Mutex
+	alg    Algorithm
+	seed   []byte
+	stream cipher.Stream
+	buf    [bufSize]byte
+	pos    int
+}
+
+// New creates a new DRBG from the seed. The seed must be SeedSize
+// bytes long.
+func New(alg Algorithm, seed []byte) (*DRBG, error) {
+	if len(seed) != SeedSize {
+		return nil, fmt.Errorf("invalid DRBG seed size %d", len(seed))
+	}
+	var stream cipher.Stream
+
+	switch alg {
+	case AESCTR:
+		block, err := aes.NewCipher(seed)
+		if err != nil {
+			return nil, err
+		}
+		var iv [aes.BlockSize]byte
+		stream = cipher.NewCTR(block, iv[:])
+
+	case ChaCha20:
+		var nonce [chacha20.NonceSize]byte
+		c, err := chacha20.NewUnauthenticatedCipher(seed, nonce[:])
+		if err != nil {
+			return nil, err
+		}
+		stream = c
+
+	default:
+		return nil, fmt.Errorf("invalid DRBG algorithm: %v", alg)
+	}
+
+	return &DRBG{
+		alg:    alg,
+		seed:   append([]byte(nil), seed...),
+		stream: stream,
+		pos:    bufSize,
+	}, nil
+}
+
+// NewFromReader creates a new DRBG seeded from the reader.
+func NewFromReader(alg Algorithm, r io.Reader) (*DRBG, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(r, seed); err != nil {
+		return nil, err
+	}
+	return New(alg, seed)
+}
+
+// NewFromOS creates a new DRBG seeded from the operating system's
+// random number generator.
+func NewFromOS(alg Algorithm) (*DRBG, error) {
+	return NewFromReader(alg, rand.Reader)
+}
+
+// Algorithm returns the DRBG algorithm.
+func (d *DRBG) Algorithm() Algorithm {
+	return d.alg
+}
+
+// Seed returns the DRBG seed. The seed determines all DRBG output and
+// it must be kept secret.
+func (d *DRBG) Seed() []byte {
+	return append([]byte(nil), d.seed...)
+}
+
+// Read implements io.Reader.Read. The function always fills p.
+func (d *DRBG) Read(p []byte) (int, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	var n int
+	for n < len(p) {
+		if d.pos >= bufSize {
+			clear(d.buf[:])
+			d.stream.XORKeyStream(d.buf[:], d.buf[:])
+			d.pos = 0
+		}
+		l := copy(p[n:], d.buf[d.pos:])
+		clear(d.buf[d.pos : d.pos+l])
+		d.pos += l
+		n += l
+	}
+	return n, nil
+}
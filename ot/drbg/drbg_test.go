@@ -0,0 +1,115 @@
+//
+// drbg_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package drbg
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+var testSeed = bytes.Repeat([]byte{0x5a}, SeedSize)
+
+func keyStream(t *testing.T, alg Algorithm, n int) []byte {
+	var stream cipher.Stream
+	switch alg {
+	case AESCTR:
+		block, err := aes.NewCipher(testSeed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream = cipher.NewCTR(block, make([]byte, aes.BlockSize))
+	case ChaCha20:
+		c, err := chacha20.NewUnauthenticatedCipher(testSeed,
+			make([]byte, chacha20.NonceSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream = c
+	}
+	buf := make([]byte, n)
+	stream.XORKeyStream(buf, buf)
+	return buf
+}
+
+func TestDRBG(t *testing.T) {
+	const size = 3*bufSize + 123
+
+	for _, alg := range []Algorithm{AESCTR, ChaCha20} {
+		expected := keyStream(t, alg, size)
+
+		// The output does not depend on the read sizes.
+		for _, chunk := range []int{1, 16, 100, bufSize, size} {
+			d, err := New(alg, testSeed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out []byte
+			for len(out) < size {
+				buf := make([]byte, min(chunk, size-len(out)))
+				n, err := d.Read(buf)
+				if err != nil || n != len(buf) {
+					t.Fatalf("%v: Read=%v, %v", alg, n, err)
+				}
+				out = append(out, buf...)
+			}
+			if !bytes.Equal(out, expected) {
+				t.Errorf("%v: chunk %d: output differs from key stream",
+					alg, chunk)
+			}
+		}
+	}
+}
+
+func TestDRBGSeed(t *testing.T) {
+	if _, err := New(AESCTR, testSeed[1:]); err == nil {
+		t.Errorf("short seed accepted")
+	}
+	if _, err := New(Algorithm(42), testSeed); err == nil {
+		t.Errorf("invalid algorithm accepted")
+	}
+	d, err := NewFromOS(ChaCha20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Algorithm() != ChaCha20 || len(d.Seed()) != SeedSize {
+		t.Errorf("invalid DRBG %v seed %x", d.Algorithm(), d.Seed())
+	}
+	for _, alg := range []Algorithm{AESCTR, ChaCha20} {
+		parsed, err := ParseAlgorithm(alg.String())
+		if err != nil || parsed != alg {
+			t.Errorf("ParseAlgorithm(%v)=%v, %v", alg, parsed, err)
+		}
+	}
+}
+
+func benchmarkLabels(b *testing.B, read func([]byte) (int, error)) {
+	var buf [16]byte
+	for i := 0; i < b.N; i++ {
+		read(buf[:])
+	}
+}
+
+func BenchmarkLabelsOS(b *testing.B) {
+	benchmarkLabels(b, rand.Read)
+}
+
+func BenchmarkLabelsAESCTR(b *testing.B) {
+	d, _ := New(AESCTR, testSeed)
+	benchmarkLabels(b, d.Read)
+}
+
+func BenchmarkLabelsChaCha20(b *testing.B) {
+	d, _ := New(ChaCha20, testSeed)
+	benchmarkLabels(b, d.Read)
+}
@@ -0,0 +1,217 @@
+package ot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxOutboxWait limits how long the HTTP gateway Outbox requests
+// wait for the messages.
+const MaxOutboxWait = 5 * time.Minute
+
+// Gateway implements an HTTP/JSON front end for the session manager.
+// The messages are encoded with the protobuf JSON mapping where the
+// message values are base64 strings. The gateway provides the
+// following endpoints:
+//
+//	POST /v1/sessions              NewSession(SessionConfig) SessionId
+//	GET  /v1/sessions/{sid}        GetSessionConfig(SessionId) SessionConfig
+//	POST /v1/inbox                 Inbox(VecMessage) Void
+//	POST /v1/outbox?wait=<dur>     Outbox(VecMessage) VecMessage
+//	GET  /v1/ping                  Ping(Void) EchoMessage
+//
+// The Outbox requests long-poll until all requested messages are
+// available or the wait duration expires.
+type Gateway struct {
+	server  pb.MpcSessionManagerServer
+	maxBody int64
+	mux     *http.ServeMux
+}
+
+// NewGateway creates a new HTTP gateway for the session manager
+// server. The maxBody limits the request body size.
+func NewGateway(server pb.MpcSessionManagerServer, maxBody int64) *Gateway {
+	gw := &Gateway{
+		server:  server,
+		maxBody: maxBody,
+		mux:     http.NewServeMux(),
+	}
+	gw.mux.HandleFunc("POST /v1/sessions", gw.newSession)
+	gw.mux.HandleFunc("GET /v1/sessions/{sid}", gw.getSessionConfig)
+	gw.mux.HandleFunc("POST /v1/inbox", gw.inbox)
+	gw.mux.HandleFunc("POST /v1/outbox", gw.outbox)
+	gw.mux.HandleFunc("GET /v1/ping", gw.ping)
+
+	return gw
+}
+
+func (gw *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	gw.mux.ServeHTTP(w, r)
+}
+
+// context returns the request context with the caller's peer
+// information.
+func (gw *Gateway) context(r *http.Request) context.Context {
+	ctx := r.Context()
+	addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	return ctx
+}
+
+func (gw *Gateway) readRequest(w http.ResponseWriter, r *http.Request,
+	msg proto.Message) bool {
+
+	body := r.Body
+	if gw.maxBody > 0 {
+		body = http.MaxBytesReader(w, r.Body, gw.maxBody)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		writeError(w, status.Error(codes.ResourceExhausted, err.Error()))
+		return false
+	}
+	if len(data) == 0 {
+		return true
+	}
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return false
+	}
+	return true
+}
+
+func writeResponse(w http.ResponseWriter, msg proto.Message) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+
+	var code int
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.AlreadyExists:
+		code = http.StatusConflict
+	case codes.ResourceExhausted:
+		code = http.StatusTooManyRequests
+	case codes.Canceled:
+		code = 499
+	case codes.DeadlineExceeded:
+		code = http.StatusGatewayTimeout
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	default:
+		code = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		Code:    st.Code().String(),
+		Message: st.Message(),
+	})
+}
+
+func (gw *Gateway) newSession(w http.ResponseWriter, r *http.Request) {
+	req := new(pb.SessionConfig)
+	if !gw.readRequest(w, r, req) {
+		return
+	}
+	resp, err := gw.server.NewSession(gw.context(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResponse(w, resp)
+}
+
+func (gw *Gateway) getSessionConfig(w http.ResponseWriter, r *http.Request) {
+	req := &pb.SessionId{
+		Value: r.PathValue("sid"),
+	}
+	resp, err := gw.server.GetSessionConfig(gw.context(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResponse(w, resp)
+}
+
+func (gw *Gateway) inbox(w http.ResponseWriter, r *http.Request) {
+	req := new(pb.VecMessage)
+	if !gw.readRequest(w, r, req) {
+		return
+	}
+	resp, err := gw.server.Inbox(gw.context(r), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResponse(w, resp)
+}
+
+func (gw *Gateway) outbox(w http.ResponseWriter, r *http.Request) {
+	req := new(pb.VecMessage)
+	if !gw.readRequest(w, r, req) {
+		return
+	}
+	wait := SESSION_TIMEOUT
+	if v := r.URL.Query().Get("wait"); len(v) > 0 {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		wait = d
+	}
+	if wait > MaxOutboxWait {
+		wait = MaxOutboxWait
+	}
+	ctx, cancel := context.WithTimeout(gw.context(r), wait)
+	defer cancel()
+
+	resp, err := gw.server.Outbox(ctx, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResponse(w, resp)
+}
+
+func (gw *Gateway) ping(w http.ResponseWriter, r *http.Request) {
+	resp, err := gw.server.Ping(gw.context(r), &pb.Void{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResponse(w, resp)
+}
@@ -0,0 +1,98 @@
+package ot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gatewayRequest(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("http.NewRequest: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func TestGateway(t *testing.T) {
+	srv := httptest.NewServer(NewGateway(NewServer(), 1024*1024))
+	defer srv.Close()
+
+	code, body := gatewayRequest(t, "POST", srv.URL+"/v1/sessions",
+		`{"operation": "bip32"}`)
+	if code != http.StatusOK {
+		t.Fatalf("NewSession: %v %v", code, body)
+	}
+	var sid struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(body), &sid); err != nil {
+		t.Fatalf("NewSession response: %v", err)
+	}
+
+	code, body = gatewayRequest(t, "GET", srv.URL+"/v1/sessions/"+sid.Value, "")
+	if code != http.StatusOK || !strings.Contains(body, `"bip32"`) {
+		t.Fatalf("GetSessionConfig: %v %v", code, body)
+	}
+	code, _ = gatewayRequest(t, "GET", srv.URL+"/v1/sessions/unknown", "")
+	if code != http.StatusNotFound {
+		t.Fatalf("GetSessionConfig: unexpected status %v", code)
+	}
+
+	// Long-poll Outbox before the message is posted.
+	type result struct {
+		code int
+		body string
+	}
+	ch := make(chan result)
+	query := `{"values": [{"sid": "` + sid.Value +
+		`", "topic": "t", "src": 1, "dst": 2, "seq": 1}]}`
+	go func() {
+		resp, err := http.Post(srv.URL+"/v1/outbox?wait=10s",
+			"application/json", strings.NewReader(query))
+		if err != nil {
+			ch <- result{body: err.Error()}
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		ch <- result{resp.StatusCode, string(data)}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	msg := `{"values": [{"sid": "` + sid.Value +
+		`", "topic": "t", "src": 1, "dst": 2, "seq": 1, "val": "aGVsbG8="}]}`
+	code, body = gatewayRequest(t, "POST", srv.URL+"/v1/inbox", msg)
+	if code != http.StatusOK {
+		t.Fatalf("Inbox: %v %v", code, body)
+	}
+	code, _ = gatewayRequest(t, "POST", srv.URL+"/v1/inbox", msg)
+	if code != http.StatusConflict {
+		t.Fatalf("Inbox: unexpected status %v", code)
+	}
+
+	r := <-ch
+	if r.code != http.StatusOK || !strings.Contains(r.body, `"aGVsbG8="`) {
+		t.Fatalf("Outbox: %v %v", r.code, r.body)
+	}
+
+	code, body = gatewayRequest(t, "POST", srv.URL+"/v1/outbox?wait=300ms",
+		strings.Replace(query, `"seq": 1`, `"seq": 2`, 1))
+	if code != http.StatusGatewayTimeout {
+		t.Fatalf("Outbox: unexpected response %v %v", code, body)
+	}
+}
@@ -24,6 +24,15 @@ func (w Wire) String() string {
 	return fmt.Sprintf("%s/%s", w.L0, w.L1)
 }
 
+// Not returns the wire with swapped labels. The returned wire encodes
+// the negation of the wire's value.
+func (w Wire) Not() Wire {
+	return Wire{
+		L0: w.L1,
+		L1: w.L0,
+	}
+}
+
 // Label implements a 128 bit wire label.
 type Label struct {
 	D0 uint64
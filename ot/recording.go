@@ -0,0 +1,202 @@
+//
+// recording.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package ot
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/ot/drbg"
+)
+
+// RecordingVersion specifies the version of the connection recording
+// format.
+const RecordingVersion = 1
+
+// ErrReplayMismatch is returned if the replayed protocol run diverges
+// from the recording.
+var ErrReplayMismatch = errors.New("replay diverges from recording")
+
+// RecordingHeader starts the connection recording. The DRBG and Seed
+// specify the session DRBG of the recorded party. The replaying
+// connection returns the recorded DRBG from SessionDRBG so that the
+// replay reproduces the recorded run.
+//
+// The recording is confidential. The seed determines all labels and
+// OT secrets of the recorded party, and with the recorded messages it
+// reveals the party's inputs and outputs. Store recordings like the
+// party's private keys.
+type RecordingHeader struct {
+	Version uint32
+	Session string
+	Garbler bool
+	DRBG    drbg.Algorithm
+	Seed    []byte
+}
+
+// Record holds one recorded message.
+type Record struct {
+	Sent  bool
+	Topic string
+	Seq   int
+	Data  []byte
+}
+
+func (r Record) String() string {
+	dir := "recv"
+	if r.Sent {
+		dir = "send"
+	}
+	return fmt.Sprintf("%s %s #%d (%d bytes)", dir, r.Topic, r.Seq,
+		len(r.Data))
+}
+
+// Record starts recording all sent and received protocol messages of
+// the connection to the writer. The recording header holds the session
+// DRBG that the protocol run sets with SessionDRBG. The recording is
+// confidential, see RecordingHeader.
+func (c *Conn) Record(w io.Writer) error {
+	if c.recorder != nil || c.replay != nil {
+		return errors.New("connection already recorded or replayed")
+	}
+	c.recorder = gob.NewEncoder(w)
+	c.header = &RecordingHeader{
+		Version: RecordingVersion,
+		Session: c.SessionId(),
+		Garbler: c.je == 1,
+	}
+	return nil
+}
+
+// SessionDRBG sets the session DRBG of the protocol run on the
+// connection. The recording connection stores the algorithm and seed
+// of the DRBG in the recording header and returns the DRBG. The
+// replaying connection returns the recorded DRBG. Other connections
+// return the DRBG as-is. A recording holds one protocol run and its
+// DRBG must be set before the first message.
+func (c *Conn) SessionDRBG(r *drbg.DRBG) (*drbg.DRBG, error) {
+	switch {
+	case c.replay != nil:
+		if c.header.Seed == nil {
+			return nil, errors.New("recording without session DRBG")
+		}
+		return drbg.New(c.header.DRBG, c.header.Seed)
+
+	case c.recorder != nil:
+		if c.written {
+			return nil, errors.New("session DRBG set after recording started")
+		}
+		c.header.DRBG = r.Algorithm()
+		c.header.Seed = r.Seed()
+		if err := c.writeHeader(); err != nil {
+			return nil, err
+		}
+		return r, nil
+
+	default:
+		return r, nil
+	}
+}
+
+// writeHeader writes the recording header.
+func (c *Conn) writeHeader() error {
+	c.written = true
+	err := c.recorder.Encode(c.header)
+	if err != nil {
+		return errors.Wrap(err, "in mpc_hd::Conn::Record(&self, ...)")
+	}
+	return nil
+}
+
+func (c *Conn) record(sent bool, topic string, seq int, data []byte) error {
+	if c.recorder == nil {
+		return nil
+	}
+	if !c.written {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return c.recorder.Encode(&Record{
+		Sent:  sent,
+		Topic: topic,
+		Seq:   seq,
+		Data:  data,
+	})
+}
+
+// NewReplayConn creates a connection that replays the recorded
+// connection from the reader. The received messages are read from the
+// recording and the sent messages must match the recorded messages.
+// The function returns the connection and the recording header.
+func NewReplayConn(r io.Reader) (*Conn, *RecordingHeader, error) {
+	dec := gob.NewDecoder(r)
+	hdr := new(RecordingHeader)
+	if err := dec.Decode(hdr); err != nil {
+		err = errors.Wrap(err, "in mpc_hd::NewReplayConn(...)")
+		return nil, nil, err
+	}
+	if hdr.Version != RecordingVersion {
+		return nil, nil, fmt.Errorf("unsupported recording version %d",
+			hdr.Version)
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	c := &Conn{
+		transcript: NewTranscript(),
+		replay:     dec,
+		header:     hdr,
+		session:    hdr.Session,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	if hdr.Garbler {
+		c.je, c.tu = 1, 2
+	} else {
+		c.je, c.tu = 2, 1
+	}
+	return c, hdr, nil
+}
+
+// replayNext returns the next recorded message and verifies that it
+// matches the expected direction, topic, and sequence number.
+func (c *Conn) replayNext(sent bool, topic string, seq int) (*Record, error) {
+	rec := new(Record)
+	if err := c.replay.Decode(rec); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if rec.Sent != sent || rec.Topic != topic || rec.Seq != seq {
+		want := Record{
+			Sent:  sent,
+			Topic: topic,
+			Seq:   seq,
+		}
+		return nil, errors.Wrapf(ErrReplayMismatch, "%v, recorded %v",
+			want, rec)
+	}
+	return rec, nil
+}
+
+// replaySend verifies the sent message data against the recording.
+func (c *Conn) replaySend(topic string, seq int, data []byte) error {
+	rec, err := c.replayNext(true, topic, seq)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(rec.Data, data) {
+		return errors.Wrapf(ErrReplayMismatch, "%v: data differs", rec)
+	}
+	return nil
+}
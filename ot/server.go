@@ -0,0 +1,186 @@
+package ot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ServerConfig configures the messenger server.
+type ServerConfig struct {
+	// Addr is the listen address of the server.
+	Addr string
+
+	// Limits configures the server resource limits.
+	Limits Limits
+
+	// SessionTTL specifies how long sessions and messages are kept
+	// and CleanupInterval how often expired items are removed.
+	SessionTTL      time.Duration
+	CleanupInterval time.Duration
+
+	// DrainTimeout limits how long the server waits for in-flight
+	// sessions to finish on shutdown. DrainIdle specifies how long
+	// the sessions must be idle before they are considered finished.
+	DrainTimeout time.Duration
+	DrainIdle    time.Duration
+
+	// HTTPAddr is the listen address of the HTTP/JSON gateway. The
+	// gateway is disabled if the address is empty.
+	HTTPAddr string
+
+	// AdminToken authenticates the clients of the admin service. The
+	// admin service is disabled if the token is empty.
+	AdminToken string
+}
+
+// DefaultServerConfig returns the default messenger server
+// configuration.
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Addr:            "127.0.0.1:65534",
+		Limits:          DefaultLimits(),
+		SessionTTL:      SESSION_TIMEOUT,
+		CleanupInterval: 180 * time.Second,
+		DrainTimeout:    30 * time.Second,
+		DrainIdle:       5 * time.Second,
+	}
+}
+
+// maxRecvMsgSize returns the gRPC receive limit for the
+// configuration.
+func (cfg ServerConfig) maxRecvMsgSize() int {
+	size := 1048576 * 32
+	if cfg.Limits.MaxMessageBytes+1048576 > size {
+		size = cfg.Limits.MaxMessageBytes + 1048576
+	}
+	return size
+}
+
+// Serve runs the messenger server until the context is done. The
+// server then stops accepting new sessions, lets in-flight sessions
+// finish up to cfg.DrainTimeout, and stops gracefully.
+func Serve(ctx context.Context, cfg ServerConfig) error {
+	sock, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen to %s", cfg.Addr)
+	}
+	return ServeListener(ctx, sock, cfg)
+}
+
+// ServeListener is like Serve but it accepts connections from the
+// argument listener.
+func ServeListener(ctx context.Context, sock net.Listener,
+	cfg ServerConfig) error {
+
+	grpc_server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(cfg.maxRecvMsgSize()),
+	)
+	// See `func main()` of the following link to learn how to configure HTTPS.
+	// https://github.com/grpc/grpc-go/blob/master/examples/route_guide/server/server.go
+
+	messenger := NewServerWithConfig(cfg)
+	healthServer := health.NewServer()
+
+	pb.RegisterMpcSessionManagerServer(grpc_server, messenger)
+	grpc_health_v1.RegisterHealthServer(grpc_server, healthServer)
+	if len(cfg.AdminToken) > 0 {
+		pb.RegisterMpcSessionAdminServer(grpc_server,
+			NewAdminServer(messenger, cfg.AdminToken))
+	}
+
+	var httpServer *http.Server
+	var httpSock net.Listener
+	if len(cfg.HTTPAddr) > 0 {
+		var err error
+		httpSock, err = net.Listen("tcp", cfg.HTTPAddr)
+		if err != nil {
+			sock.Close()
+			return errors.Wrapf(err, "failed to listen to %s", cfg.HTTPAddr)
+		}
+		// The message values are base64 encoded in JSON.
+		httpServer = &http.Server{
+			Handler: NewGateway(messenger,
+				int64(cfg.maxRecvMsgSize())*2),
+		}
+	}
+
+	errc := make(chan error, 2)
+	go func() {
+		errc <- grpc_server.Serve(sock)
+	}()
+	if httpServer != nil {
+		go func() {
+			err := httpServer.Serve(httpSock)
+			if err == http.ErrServerClosed {
+				return
+			}
+			errc <- err
+		}()
+	}
+
+	select {
+	case err := <-errc:
+		healthServer.Shutdown()
+		grpc_server.Stop()
+		if httpServer != nil {
+			httpServer.Close()
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("draining messenger at %s...", sock.Addr())
+	healthServer.Shutdown()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(),
+		cfg.DrainTimeout)
+	defer cancel()
+
+	messenger.Drain(drainCtx, cfg.DrainIdle)
+
+	if httpServer != nil {
+		if err := httpServer.Shutdown(drainCtx); err != nil {
+			httpServer.Close()
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpc_server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-drainCtx.Done():
+		log.Printf("drain timeout, stopping messenger")
+		grpc_server.Stop()
+		<-stopped
+	}
+	return <-errc
+}
+
+// SpawnServer runs the messenger server at host:port with the default
+// configuration until the process receives SIGINT or SIGTERM.
+func SpawnServer(host string, port uint16) error {
+	cfg := DefaultServerConfig()
+	cfg.Addr = fmt.Sprintf("%s:%d", host, port)
+
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return Serve(ctx, cfg)
+}
@@ -4,49 +4,56 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
-	"log"
-	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/markkurossi/mpc/pb"
 	"github.com/patrickmn/go-cache"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 const SESSION_TIMEOUT = time.Second * 60
 
-func SpawnServer(host string, port uint16) {
-	hp := fmt.Sprintf("%s:%d", host, port)
-	sock, err := net.Listen("tcp", hp)
-	if err != nil {
-		log.Println("failed to listen to", hp)
-		panic(err)
-	}
-
-	grpc_server := grpc.NewServer(
-		grpc.MaxRecvMsgSize(1048576 * 32),
-	)
-	// See `func main()` of the following link to learn how to configure HTTPS.
-	// https://github.com/grpc/grpc-go/blob/master/examples/route_guide/server/server.go
-
-	pb.RegisterMpcSessionManagerServer(grpc_server, NewServer())
-	grpc_server.Serve(sock)
-}
-
 type MessengerServer struct {
 	pb.UnimplementedMpcSessionManagerServer
-	void *pb.Void
-	db   *cache.Cache
-	db2  *cache.Cache
+	void     *pb.Void
+	db       *cache.Cache
+	db2      *cache.Cache
+	usage    *usage
+	limiter  *rateLimiter
+	draining atomic.Bool
+	activity atomic.Int64
 }
 
+// NewServer creates a new messenger server with the default limits.
 func NewServer() *MessengerServer {
+	return NewServerWithLimits(DefaultLimits())
+}
+
+// NewServerWithLimits creates a new messenger server with the
+// argument limits.
+func NewServerWithLimits(limits Limits) *MessengerServer {
+	cfg := DefaultServerConfig()
+	cfg.Limits = limits
+	return NewServerWithConfig(cfg)
+}
+
+// NewServerWithConfig creates a new messenger server with the
+// argument configuration.
+func NewServerWithConfig(cfg ServerConfig) *MessengerServer {
 	s := &MessengerServer{}
 	s.void = &pb.Void{}
-	s.db = cache.New(SESSION_TIMEOUT, 180*time.Second)
+	s.db = cache.New(cfg.SessionTTL, cfg.CleanupInterval)
+	s.usage = newUsage(cfg.Limits)
+	s.limiter = newRateLimiter(cfg.Limits.NewSessionRate,
+		cfg.Limits.NewSessionBurst)
+	s.db.OnEvicted(func(key string, obj interface{}) {
+		if msg, ok := obj.(*storedMessage); ok {
+			s.usage.release(key, msg)
+		}
+	})
 	return s
 }
 
@@ -54,6 +61,16 @@ func (s *MessengerServer) NewSession(
 	ctx context.Context,
 	cfg *pb.SessionConfig,
 ) (*pb.SessionId, error) {
+	if s.draining.Load() {
+		return nil, status.Error(codes.Unavailable,
+			"messenger is shutting down")
+	}
+	caller := callerID(ctx)
+	if !s.limiter.allow(caller, time.Now()) {
+		return nil, status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("NewSession rate limit exceeded for %s", caller))
+	}
+
 	// If field `SessionId` is not provided,
 	// then create with UUID-v7, in lowercase hex string --WITHOUT-- hyphens.
 	if cfg.SessionId == "" {
@@ -87,12 +104,37 @@ func (s *MessengerServer) Inbox(
 	ctx context.Context,
 	req *pb.VecMessage,
 ) (*pb.Void, error) {
+	s.touch()
 	vec_msg := req.Values
 	for _, msg := range vec_msg {
 		key := PrimaryKey(msg.Sid, msg.Topic, msg.Src, msg.Dst, msg.Seq)
 		_, found := s.db.Get(key)
 		if !found {
-			s.db.Add(key, msg.Val, cache.DefaultExpiration)
+			stored := &storedMessage{
+				Sid:     msg.Sid,
+				Topic:   msg.Topic,
+				Src:     msg.Src,
+				Dst:     msg.Dst,
+				Seq:     msg.Seq,
+				Val:     msg.Val,
+				Created: time.Now(),
+			}
+			err := s.usage.reserve(key, stored, func() bool {
+				_, found := s.db.Get(key)
+				return !found
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := s.db.Add(key, stored, cache.DefaultExpiration); err != nil {
+				// Concurrent Inbox for the same key.
+				s.usage.release(key, stored)
+				err = status.Error(
+					codes.AlreadyExists,
+					fmt.Sprintf("message key [%s, %s, %d, %d, %d] already exists", msg.Sid, msg.Topic, msg.Src, msg.Dst, msg.Seq),
+				)
+				return nil, err
+			}
 		} else {
 			err := status.Error(
 				codes.AlreadyExists,
@@ -108,6 +150,7 @@ func (s *MessengerServer) Outbox(
 	ctx context.Context,
 	req *pb.VecMessage,
 ) (*pb.VecMessage, error) {
+	s.touch()
 	vec_req := req.Values
 	vec_resp := &pb.VecMessage{Values: make([]*pb.Message, len(vec_req))}
 	for i, req := range vec_req {
@@ -124,10 +167,14 @@ func (s *MessengerServer) Outbox(
 			}
 			// #endregion
 
-			time.Sleep(250 * time.Millisecond)
+			select {
+			case <-ctx.Done():
+				return nil, status.FromContextError(ctx.Err()).Err()
+			case <-time.After(250 * time.Millisecond):
+			}
 			obj, found = s.db.Get(key)
 		}
-		req.Val = obj.([]byte)
+		req.Val = obj.(*storedMessage).Val
 		vec_resp.Values[i] = req
 	}
 	return vec_resp, nil
@@ -142,3 +189,43 @@ func (s *MessengerServer) Ping(
 	}
 	return msg, nil
 }
+
+// touch records session activity for draining.
+func (s *MessengerServer) touch() {
+	s.activity.Store(time.Now().UnixNano())
+}
+
+// Drain stops the server from accepting new sessions and waits until
+// the existing sessions have been idle for the idle duration or the
+// context is done.
+func (s *MessengerServer) Drain(ctx context.Context, idle time.Duration) {
+	s.draining.Store(true)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		last := time.Unix(0, s.activity.Load())
+		if time.Since(last) >= idle {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireSession removes the session and its pending messages. The
+// function returns true if the session existed and the number of
+// removed messages.
+func (s *MessengerServer) ExpireSession(sid string) (bool, int) {
+	msgs := s.usage.messages(sid)
+	for key := range msgs {
+		s.db.Delete(key)
+	}
+	_, found := s.db.Get(sid)
+	s.db.Delete(sid)
+
+	return found || len(msgs) > 0, len(msgs)
+}
@@ -0,0 +1,126 @@
+package ot
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/markkurossi/mpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func inboxMessage(sid string, seq, size int) *pb.VecMessage {
+	return &pb.VecMessage{
+		Values: []*pb.Message{
+			{
+				Sid:   sid,
+				Topic: "test",
+				Src:   1,
+				Dst:   2,
+				Seq:   uint64(seq),
+				Val:   make([]byte, size),
+			},
+		},
+	}
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("unexpected error: got %v, expected %v", err, code)
+	}
+}
+
+func TestServerSessionLimits(t *testing.T) {
+	s := NewServerWithLimits(Limits{
+		MaxMessageBytes:    100,
+		MaxSessionBytes:    250,
+		MaxSessionMessages: 3,
+	})
+	ctx := context.Background()
+
+	_, err := s.Inbox(ctx, inboxMessage("a", 1, 101))
+	expectCode(t, err, codes.ResourceExhausted)
+
+	for seq := 1; seq <= 2; seq++ {
+		if _, err := s.Inbox(ctx, inboxMessage("a", seq, 100)); err != nil {
+			t.Fatalf("Inbox failed: %v", err)
+		}
+	}
+	_, err = s.Inbox(ctx, inboxMessage("a", 3, 100))
+	expectCode(t, err, codes.ResourceExhausted)
+
+	if _, err := s.Inbox(ctx, inboxMessage("a", 3, 10)); err != nil {
+		t.Fatalf("Inbox failed: %v", err)
+	}
+	_, err = s.Inbox(ctx, inboxMessage("a", 4, 1))
+	expectCode(t, err, codes.ResourceExhausted)
+
+	// Other sessions are not affected.
+	if _, err := s.Inbox(ctx, inboxMessage("b", 1, 100)); err != nil {
+		t.Fatalf("Inbox failed: %v", err)
+	}
+
+	_, err = s.Inbox(ctx, inboxMessage("b", 1, 10))
+	expectCode(t, err, codes.AlreadyExists)
+}
+
+func TestServerTotalLimits(t *testing.T) {
+	s := NewServerWithLimits(Limits{
+		MaxTotalBytes:    150,
+		MaxTotalMessages: 3,
+	})
+	ctx := context.Background()
+
+	if _, err := s.Inbox(ctx, inboxMessage("a", 1, 100)); err != nil {
+		t.Fatalf("Inbox failed: %v", err)
+	}
+	_, err := s.Inbox(ctx, inboxMessage("b", 1, 100))
+	expectCode(t, err, codes.ResourceExhausted)
+
+	for _, sid := range []string{"b", "c"} {
+		if _, err := s.Inbox(ctx, inboxMessage(sid, 1, 10)); err != nil {
+			t.Fatalf("Inbox failed: %v", err)
+		}
+	}
+	_, err = s.Inbox(ctx, inboxMessage("d", 1, 1))
+	expectCode(t, err, codes.ResourceExhausted)
+
+	// Expired messages release their quota.
+	s.db.Delete(PrimaryKey("a", "test", uint64(1), uint64(2), uint64(1)))
+	if _, err := s.Inbox(ctx, inboxMessage("d", 1, 100)); err != nil {
+		t.Fatalf("Inbox failed: %v", err)
+	}
+}
+
+func TestServerNewSessionRate(t *testing.T) {
+	s := NewServerWithLimits(Limits{
+		NewSessionRate:  1,
+		NewSessionBurst: 2,
+	})
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4242},
+	})
+	other := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 4242},
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.NewSession(ctx, &pb.SessionConfig{}); err != nil {
+			t.Fatalf("NewSession failed: %v", err)
+		}
+	}
+	_, err := s.NewSession(ctx, &pb.SessionConfig{})
+	expectCode(t, err, codes.ResourceExhausted)
+
+	if _, err := s.NewSession(other, &pb.SessionConfig{}); err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+
+	if !s.limiter.allow("10.0.0.1", time.Now().Add(time.Second)) {
+		t.Fatalf("rate limiter did not refill")
+	}
+}
@@ -0,0 +1,284 @@
+package ot
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Limits configures the resource limits of the messenger server. A
+// zero value disables the corresponding limit.
+type Limits struct {
+	// MaxMessageBytes limits the size of a single message.
+	MaxMessageBytes int
+
+	// MaxSessionBytes and MaxSessionMessages limit the pending
+	// messages of a single session.
+	MaxSessionBytes    int64
+	MaxSessionMessages int
+
+	// MaxTotalBytes and MaxTotalMessages limit the pending messages
+	// of all sessions together.
+	MaxTotalBytes    int64
+	MaxTotalMessages int
+
+	// NewSessionRate limits the sustained number of NewSession calls
+	// per second from a single caller. NewSessionBurst is the number
+	// of calls the caller can make in a burst.
+	NewSessionRate  float64
+	NewSessionBurst int
+}
+
+// DefaultLimits returns the default messenger limits.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxMessageBytes:    1048576 * 32,
+		MaxSessionBytes:    1048576 * 256,
+		MaxSessionMessages: 4096,
+		MaxTotalBytes:      1048576 * 2048,
+		MaxTotalMessages:   1048576,
+		NewSessionRate:     10,
+		NewSessionBurst:    50,
+	}
+}
+
+// storedMessage holds a pending message and the metadata needed for
+// resource accounting.
+type storedMessage struct {
+	Sid     string
+	Topic   string
+	Src     uint64
+	Dst     uint64
+	Seq     uint64
+	Val     []byte
+	Created time.Time
+}
+
+// sessionUsage tracks the pending messages of a session.
+type sessionUsage struct {
+	bytes int64
+	msgs  map[string]*storedMessage
+}
+
+// usage implements the per-session and global message accounting.
+type usage struct {
+	mu       sync.Mutex
+	limits   Limits
+	bytes    int64
+	count    int
+	sessions map[string]*sessionUsage
+}
+
+func newUsage(limits Limits) *usage {
+	return &usage{
+		limits:   limits,
+		sessions: make(map[string]*sessionUsage),
+	}
+}
+
+// reserve accounts the message msg with the primary key key. The
+// function returns a ResourceExhausted error if the message would
+// exceed any of the limits. The argument function stale tells if a
+// previously accounted message with the same key has already expired
+// from the store.
+func (u *usage) reserve(key string, msg *storedMessage,
+	stale func() bool) error {
+
+	l := u.limits
+	size := int64(len(msg.Val))
+
+	if l.MaxMessageBytes > 0 && len(msg.Val) > l.MaxMessageBytes {
+		return status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("message size %d exceeds limit %d",
+				len(msg.Val), l.MaxMessageBytes))
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s, ok := u.sessions[msg.Sid]
+	if !ok {
+		s = &sessionUsage{
+			msgs: make(map[string]*storedMessage),
+		}
+		u.sessions[msg.Sid] = s
+	}
+	if old, ok := s.msgs[key]; ok && stale() {
+		// The store overwrites expired entries without eviction
+		// callbacks.
+		u.releaseLocked(s, key, old)
+	}
+
+	if l.MaxSessionMessages > 0 && len(s.msgs)+1 > l.MaxSessionMessages {
+		return status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("session %s: message count limit %d exceeded",
+				msg.Sid, l.MaxSessionMessages))
+	}
+	if l.MaxSessionBytes > 0 && s.bytes+size > l.MaxSessionBytes {
+		return status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("session %s: byte limit %d exceeded",
+				msg.Sid, l.MaxSessionBytes))
+	}
+	if l.MaxTotalMessages > 0 && u.count+1 > l.MaxTotalMessages {
+		return status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("server message count limit %d exceeded",
+				l.MaxTotalMessages))
+	}
+	if l.MaxTotalBytes > 0 && u.bytes+size > l.MaxTotalBytes {
+		return status.Error(codes.ResourceExhausted,
+			fmt.Sprintf("server byte limit %d exceeded", l.MaxTotalBytes))
+	}
+
+	s.msgs[key] = msg
+	s.bytes += size
+	u.count++
+	u.bytes += size
+
+	return nil
+}
+
+// release removes the message msg from the accounting. It is a no-op
+// if the key is not accounted for msg.
+func (u *usage) release(key string, msg *storedMessage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s, ok := u.sessions[msg.Sid]
+	if !ok {
+		return
+	}
+	if s.msgs[key] != msg {
+		return
+	}
+	u.releaseLocked(s, key, msg)
+}
+
+func (u *usage) releaseLocked(s *sessionUsage, key string,
+	msg *storedMessage) {
+
+	size := int64(len(msg.Val))
+
+	delete(s.msgs, key)
+	s.bytes -= size
+	u.count--
+	u.bytes -= size
+
+	if len(s.msgs) == 0 {
+		delete(u.sessions, msg.Sid)
+	}
+}
+
+// messages returns the pending messages of the session.
+func (u *usage) messages(sid string) map[string]*storedMessage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	result := make(map[string]*storedMessage)
+	if s, ok := u.sessions[sid]; ok {
+		for key, msg := range s.msgs {
+			result[key] = msg
+		}
+	}
+	return result
+}
+
+// sessionStats returns the pending message count and bytes of all
+// sessions.
+func (u *usage) sessionStats() map[string][2]uint64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	result := make(map[string][2]uint64)
+	for sid, s := range u.sessions {
+		result[sid] = [2]uint64{uint64(len(s.msgs)), uint64(s.bytes)}
+	}
+	return result
+}
+
+// rateLimiter implements per-caller token bucket rate limiting.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow tests if the caller can make a call at time now.
+func (r *rateLimiter) allow(caller string, now time.Time) bool {
+	if r.rate <= 0 {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[caller]
+	if !ok {
+		b = &bucket{
+			tokens: r.burst,
+			last:   now,
+		}
+		r.buckets[caller] = b
+		r.pruneLocked(now)
+	}
+	b.tokens += now.Sub(b.last).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// pruneLocked removes the buckets that have been refilled to their
+// full capacity.
+func (r *rateLimiter) pruneLocked(now time.Time) {
+	if len(r.buckets) < 1024 {
+		return
+	}
+	full := time.Duration(r.burst / r.rate * float64(time.Second))
+	for caller, b := range r.buckets {
+		if now.Sub(b.last) > full {
+			delete(r.buckets, caller)
+		}
+	}
+}
+
+// callerID identifies the caller of the RPC by its network address.
+func callerID(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	addr := p.Addr.String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,56 @@
+package ot
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestServeDrain(t *testing.T) {
+	sock, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	cfg := DefaultServerConfig()
+	cfg.DrainTimeout = 2 * time.Second
+	cfg.DrainIdle = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- ServeListener(ctx, sock, cfg)
+	}()
+
+	conn, err := grpc.NewClient(sock.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	defer conn.Close()
+
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), time.Second)
+	defer reqCancel()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(reqCtx,
+		&grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("unexpected health status: %v", resp.Status)
+	}
+
+	cancel()
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("Serve failed: %v", err)
+		}
+	case <-time.After(cfg.DrainTimeout + time.Second):
+		t.Fatalf("Serve did not stop")
+	}
+}
@@ -0,0 +1,72 @@
+//
+// transcript.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package ot
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrTranscriptMismatch is returned when the peers' views of the
+// protocol transcript differ. It indicates that the relay reordered,
+// replayed, or substituted messages between the peers.
+var ErrTranscriptMismatch = errors.New("ot: protocol transcript mismatch")
+
+// Transcript implements a running hash over all protocol messages
+// exchanged between two peers. Since the peers observe sends and
+// receives in different orders, the transcript keeps a separate hash
+// chain for each direction and combines them in the party order.
+//
+// Note that the transcript detects relay faults but it does not
+// authenticate the peers: a relay that can rewrite the final
+// confirmation messages can also hide its modifications.
+type Transcript struct {
+	chains [2]hash.Hash
+}
+
+// NewTranscript creates a new empty transcript.
+func NewTranscript() *Transcript {
+	return &Transcript{
+		chains: [2]hash.Hash{sha256.New(), sha256.New()},
+	}
+}
+
+// Add adds the message from party src to party dst into the
+// transcript. The parties are numbered from 1.
+func (t *Transcript) Add(src, dst int, topic string, seq int, data []byte) {
+	h := t.chains[0]
+	if src > dst {
+		h = t.chains[1]
+	}
+	var buf [8]byte
+
+	binary.BigEndian.PutUint64(buf[:], uint64(src))
+	h.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(dst))
+	h.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(len(topic)))
+	h.Write(buf[:])
+	h.Write([]byte(topic))
+	binary.BigEndian.PutUint64(buf[:], uint64(seq))
+	h.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(len(data)))
+	h.Write(buf[:])
+	h.Write(data)
+}
+
+// Sum returns the transcript digest.
+func (t *Transcript) Sum() []byte {
+	h := sha256.New()
+	h.Write(t.chains[0].Sum(nil))
+	h.Write(t.chains[1].Sum(nil))
+	return h.Sum(nil)
+}
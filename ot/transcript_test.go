@@ -0,0 +1,47 @@
+//
+// transcript_test.go
+//
+// Copyright (c) 2026 Markku Rossi
+//
+// All rights reserved.
+//
+
+package ot
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTranscript(t *testing.T) {
+	g := NewTranscript()
+	e := NewTranscript()
+
+	// Both parties send first and receive then.
+	g.Add(1, 2, "input sizes", 1, []byte{1})
+	e.Add(2, 1, "input sizes", 1, []byte{2})
+	g.Add(2, 1, "input sizes", 1, []byte{2})
+	e.Add(1, 2, "input sizes", 1, []byte{1})
+
+	if !bytes.Equal(g.Sum(), e.Sum()) {
+		t.Fatalf("transcripts differ")
+	}
+
+	// Substituted message.
+	g.Add(1, 2, "result", 2, []byte{42})
+	e.Add(1, 2, "result", 2, []byte{43})
+	if bytes.Equal(g.Sum(), e.Sum()) {
+		t.Fatalf("substituted message not detected")
+	}
+
+	// Reordered messages.
+	g = NewTranscript()
+	e = NewTranscript()
+	g.Add(1, 2, "a", 1, []byte{1})
+	g.Add(1, 2, "b", 2, []byte{2})
+	e.Add(1, 2, "b", 2, []byte{2})
+	e.Add(1, 2, "a", 1, []byte{1})
+	if bytes.Equal(g.Sum(), e.Sum()) {
+		t.Fatalf("reordered messages not detected")
+	}
+}
@@ -0,0 +1,421 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: admin.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SessionInfo struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// config is unset for sessions that were not created with
+	// NewSession.
+	Config          *SessionConfig `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	PendingMessages uint64         `protobuf:"varint,3,opt,name=pending_messages,json=pendingMessages,proto3" json:"pending_messages,omitempty"`
+	PendingBytes    uint64         `protobuf:"varint,4,opt,name=pending_bytes,json=pendingBytes,proto3" json:"pending_bytes,omitempty"`
+	// expire_at_unix is zero for sessions without a config.
+	ExpireAtUnix  int64 `protobuf:"varint,5,opt,name=expire_at_unix,json=expireAtUnix,proto3" json:"expire_at_unix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionInfo) Reset() {
+	*x = SessionInfo{}
+	mi := &file_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInfo) ProtoMessage() {}
+
+func (x *SessionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInfo.ProtoReflect.Descriptor instead.
+func (*SessionInfo) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SessionInfo) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetConfig() *SessionConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *SessionInfo) GetPendingMessages() uint64 {
+	if x != nil {
+		return x.PendingMessages
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetPendingBytes() uint64 {
+	if x != nil {
+		return x.PendingBytes
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetExpireAtUnix() int64 {
+	if x != nil {
+		return x.ExpireAtUnix
+	}
+	return 0
+}
+
+type SessionList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*SessionInfo         `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionList) Reset() {
+	*x = SessionList{}
+	mi := &file_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionList) ProtoMessage() {}
+
+func (x *SessionList) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionList.ProtoReflect.Descriptor instead.
+func (*SessionList) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SessionList) GetSessions() []*SessionInfo {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type MessageInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Src           uint64                 `protobuf:"varint,2,opt,name=src,proto3" json:"src,omitempty"`
+	Dst           uint64                 `protobuf:"varint,3,opt,name=dst,proto3" json:"dst,omitempty"`
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	Size          uint64                 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	AgeMs         int64                  `protobuf:"varint,6,opt,name=age_ms,json=ageMs,proto3" json:"age_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageInfo) Reset() {
+	*x = MessageInfo{}
+	mi := &file_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageInfo) ProtoMessage() {}
+
+func (x *MessageInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageInfo.ProtoReflect.Descriptor instead.
+func (*MessageInfo) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MessageInfo) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *MessageInfo) GetSrc() uint64 {
+	if x != nil {
+		return x.Src
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetDst() uint64 {
+	if x != nil {
+		return x.Dst
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *MessageInfo) GetAgeMs() int64 {
+	if x != nil {
+		return x.AgeMs
+	}
+	return 0
+}
+
+type MessageInfoList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*MessageInfo         `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageInfoList) Reset() {
+	*x = MessageInfoList{}
+	mi := &file_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageInfoList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageInfoList) ProtoMessage() {}
+
+func (x *MessageInfoList) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageInfoList.ProtoReflect.Descriptor instead.
+func (*MessageInfoList) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MessageInfoList) GetMessages() []*MessageInfo {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+type ExpireResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SessionFound    bool                   `protobuf:"varint,1,opt,name=session_found,json=sessionFound,proto3" json:"session_found,omitempty"`
+	ExpiredMessages uint64                 `protobuf:"varint,2,opt,name=expired_messages,json=expiredMessages,proto3" json:"expired_messages,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ExpireResult) Reset() {
+	*x = ExpireResult{}
+	mi := &file_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireResult) ProtoMessage() {}
+
+func (x *ExpireResult) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireResult.ProtoReflect.Descriptor instead.
+func (*ExpireResult) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExpireResult) GetSessionFound() bool {
+	if x != nil {
+		return x.SessionFound
+	}
+	return false
+}
+
+func (x *ExpireResult) GetExpiredMessages() uint64 {
+	if x != nil {
+		return x.ExpiredMessages
+	}
+	return 0
+}
+
+var File_admin_proto protoreflect.FileDescriptor
+
+const file_admin_proto_rawDesc = "" +
+	"\n" +
+	"\vadmin.proto\x12\x06svarog\x1a\x0fmessenger.proto\"\xd1\x01\n" +
+	"\vSessionInfo\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12-\n" +
+	"\x06config\x18\x02 \x01(\v2\x15.svarog.SessionConfigR\x06config\x12)\n" +
+	"\x10pending_messages\x18\x03 \x01(\x04R\x0fpendingMessages\x12#\n" +
+	"\rpending_bytes\x18\x04 \x01(\x04R\fpendingBytes\x12$\n" +
+	"\x0eexpire_at_unix\x18\x05 \x01(\x03R\fexpireAtUnix\">\n" +
+	"\vSessionList\x12/\n" +
+	"\bsessions\x18\x01 \x03(\v2\x13.svarog.SessionInfoR\bsessions\"\x84\x01\n" +
+	"\vMessageInfo\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x10\n" +
+	"\x03src\x18\x02 \x01(\x04R\x03src\x12\x10\n" +
+	"\x03dst\x18\x03 \x01(\x04R\x03dst\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x04R\x04size\x12\x15\n" +
+	"\x06age_ms\x18\x06 \x01(\x03R\x05ageMs\"B\n" +
+	"\x0fMessageInfoList\x12/\n" +
+	"\bmessages\x18\x01 \x03(\v2\x13.svarog.MessageInfoR\bmessages\"^\n" +
+	"\fExpireResult\x12#\n" +
+	"\rsession_found\x18\x01 \x01(\bR\fsessionFound\x12)\n" +
+	"\x10expired_messages\x18\x02 \x01(\x04R\x0fexpiredMessages2\xba\x01\n" +
+	"\x0fMpcSessionAdmin\x121\n" +
+	"\fListSessions\x12\f.svarog.Void\x1a\x13.svarog.SessionList\x12:\n" +
+	"\fListMessages\x12\x11.svarog.SessionId\x1a\x17.svarog.MessageInfoList\x128\n" +
+	"\rExpireSession\x12\x11.svarog.SessionId\x1a\x14.svarog.ExpireResultB\x05Z\x03/pbb\x06proto3"
+
+var (
+	file_admin_proto_rawDescOnce sync.Once
+	file_admin_proto_rawDescData []byte
+)
+
+func file_admin_proto_rawDescGZIP() []byte {
+	file_admin_proto_rawDescOnce.Do(func() {
+		file_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)))
+	})
+	return file_admin_proto_rawDescData
+}
+
+var file_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_admin_proto_goTypes = []any{
+	(*SessionInfo)(nil),     // 0: svarog.SessionInfo
+	(*SessionList)(nil),     // 1: svarog.SessionList
+	(*MessageInfo)(nil),     // 2: svarog.MessageInfo
+	(*MessageInfoList)(nil), // 3: svarog.MessageInfoList
+	(*ExpireResult)(nil),    // 4: svarog.ExpireResult
+	(*SessionConfig)(nil),   // 5: svarog.SessionConfig
+	(*Void)(nil),            // 6: svarog.Void
+	(*SessionId)(nil),       // 7: svarog.SessionId
+}
+var file_admin_proto_depIdxs = []int32{
+	5, // 0: svarog.SessionInfo.config:type_name -> svarog.SessionConfig
+	0, // 1: svarog.SessionList.sessions:type_name -> svarog.SessionInfo
+	2, // 2: svarog.MessageInfoList.messages:type_name -> svarog.MessageInfo
+	6, // 3: svarog.MpcSessionAdmin.ListSessions:input_type -> svarog.Void
+	7, // 4: svarog.MpcSessionAdmin.ListMessages:input_type -> svarog.SessionId
+	7, // 5: svarog.MpcSessionAdmin.ExpireSession:input_type -> svarog.SessionId
+	1, // 6: svarog.MpcSessionAdmin.ListSessions:output_type -> svarog.SessionList
+	3, // 7: svarog.MpcSessionAdmin.ListMessages:output_type -> svarog.MessageInfoList
+	4, // 8: svarog.MpcSessionAdmin.ExpireSession:output_type -> svarog.ExpireResult
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_admin_proto_init() }
+func file_admin_proto_init() {
+	if File_admin_proto != nil {
+		return
+	}
+	file_messenger_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_proto_goTypes,
+		DependencyIndexes: file_admin_proto_depIdxs,
+		MessageInfos:      file_admin_proto_msgTypes,
+	}.Build()
+	File_admin_proto = out.File
+	file_admin_proto_goTypes = nil
+	file_admin_proto_depIdxs = nil
+}
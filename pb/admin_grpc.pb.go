@@ -0,0 +1,203 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: admin.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MpcSessionAdmin_ListSessions_FullMethodName  = "/svarog.MpcSessionAdmin/ListSessions"
+	MpcSessionAdmin_ListMessages_FullMethodName  = "/svarog.MpcSessionAdmin/ListMessages"
+	MpcSessionAdmin_ExpireSession_FullMethodName = "/svarog.MpcSessionAdmin/ExpireSession"
+)
+
+// MpcSessionAdminClient is the client API for MpcSessionAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MpcSessionAdmin inspects and manages the sessions of the messenger.
+// The service is authenticated separately from MpcSessionManager.
+type MpcSessionAdminClient interface {
+	ListSessions(ctx context.Context, in *Void, opts ...grpc.CallOption) (*SessionList, error)
+	ListMessages(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*MessageInfoList, error)
+	ExpireSession(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*ExpireResult, error)
+}
+
+type mpcSessionAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMpcSessionAdminClient(cc grpc.ClientConnInterface) MpcSessionAdminClient {
+	return &mpcSessionAdminClient{cc}
+}
+
+func (c *mpcSessionAdminClient) ListSessions(ctx context.Context, in *Void, opts ...grpc.CallOption) (*SessionList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, MpcSessionAdmin_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpcSessionAdminClient) ListMessages(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*MessageInfoList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessageInfoList)
+	err := c.cc.Invoke(ctx, MpcSessionAdmin_ListMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpcSessionAdminClient) ExpireSession(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*ExpireResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExpireResult)
+	err := c.cc.Invoke(ctx, MpcSessionAdmin_ExpireSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MpcSessionAdminServer is the server API for MpcSessionAdmin service.
+// All implementations must embed UnimplementedMpcSessionAdminServer
+// for forward compatibility.
+//
+// MpcSessionAdmin inspects and manages the sessions of the messenger.
+// The service is authenticated separately from MpcSessionManager.
+type MpcSessionAdminServer interface {
+	ListSessions(context.Context, *Void) (*SessionList, error)
+	ListMessages(context.Context, *SessionId) (*MessageInfoList, error)
+	ExpireSession(context.Context, *SessionId) (*ExpireResult, error)
+	mustEmbedUnimplementedMpcSessionAdminServer()
+}
+
+// UnimplementedMpcSessionAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMpcSessionAdminServer struct{}
+
+func (UnimplementedMpcSessionAdminServer) ListSessions(context.Context, *Void) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedMpcSessionAdminServer) ListMessages(context.Context, *SessionId) (*MessageInfoList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessages not implemented")
+}
+func (UnimplementedMpcSessionAdminServer) ExpireSession(context.Context, *SessionId) (*ExpireResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExpireSession not implemented")
+}
+func (UnimplementedMpcSessionAdminServer) mustEmbedUnimplementedMpcSessionAdminServer() {}
+func (UnimplementedMpcSessionAdminServer) testEmbeddedByValue()                         {}
+
+// UnsafeMpcSessionAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MpcSessionAdminServer will
+// result in compilation errors.
+type UnsafeMpcSessionAdminServer interface {
+	mustEmbedUnimplementedMpcSessionAdminServer()
+}
+
+func RegisterMpcSessionAdminServer(s grpc.ServiceRegistrar, srv MpcSessionAdminServer) {
+	// If the following call pancis, it indicates UnimplementedMpcSessionAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MpcSessionAdmin_ServiceDesc, srv)
+}
+
+func _MpcSessionAdmin_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpcSessionAdminServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpcSessionAdmin_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpcSessionAdminServer).ListSessions(ctx, req.(*Void))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpcSessionAdmin_ListMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpcSessionAdminServer).ListMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpcSessionAdmin_ListMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpcSessionAdminServer).ListMessages(ctx, req.(*SessionId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpcSessionAdmin_ExpireSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpcSessionAdminServer).ExpireSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpcSessionAdmin_ExpireSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpcSessionAdminServer).ExpireSession(ctx, req.(*SessionId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MpcSessionAdmin_ServiceDesc is the grpc.ServiceDesc for MpcSessionAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MpcSessionAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "svarog.MpcSessionAdmin",
+	HandlerType: (*MpcSessionAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSessions",
+			Handler:    _MpcSessionAdmin_ListSessions_Handler,
+		},
+		{
+			MethodName: "ListMessages",
+			Handler:    _MpcSessionAdmin_ListMessages_Handler,
+		},
+		{
+			MethodName: "ExpireSession",
+			Handler:    _MpcSessionAdmin_ExpireSession_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}